@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"draft/site"
+)
+
+/*
+ * Lint the site without writing anything: `draft check config.yaml`
+ *
+ * Exits non-zero when any problem is found, warnings don't count. --json prints a report for CI
+ * instead of the usual console output. --future and --drafts check the same posts as a build
+ * run with them.
+ */
+func check(args []string) {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "print a JSON report")
+	future := flags.Bool("future", false, "include posts published in the future")
+	drafts := flags.Bool("drafts", false, "check posts with status draft as the build would render them")
+	positional := parseArgs(flags, args)
+	if len(positional) < 1 {
+		fmt.Println("Usage: draft check [--json] [--future] [--drafts] [config.yaml]")
+		os.Exit(1)
+	}
+
+	if !*jsonOutput {
+		banner()
+	}
+
+	config, err := site.LoadConfig(positional[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	s, err := site.Load(*config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	s.Future = *future
+	s.Drafts = *drafts
+
+	report, err := s.Check(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Check failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		for _, problem := range report.Problems {
+			fmt.Printf("❌ %s\n", problem)
+		}
+		for _, warning := range report.Warnings {
+			fmt.Printf("⚠️  %s\n", warning)
+		}
+		if len(report.Problems) == 0 {
+			fmt.Printf("✅ Checked %d post(s), no problems found\n", report.Posts)
+		} else {
+			fmt.Printf("🚨 Checked %d post(s), found %d problem(s)\n", report.Posts, len(report.Problems))
+		}
+	}
+
+	if len(report.Problems) > 0 {
+		os.Exit(1)
+	}
+}
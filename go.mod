@@ -2,9 +2,17 @@ module draft
 
 go 1.23.2
 
-require github.com/gomarkdown/markdown v0.0.0-20241105142532-d03b89096d81
+require (
+	github.com/alecthomas/chroma/v2 v2.20.0
+	github.com/gomarkdown/markdown v0.0.0-20241105142532-d03b89096d81
+	github.com/google/go-cmp v0.6.0
+	github.com/tdewolff/minify/v2 v2.21.3
+	golang.org/x/image v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
-	github.com/google/go-cmp v0.6.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	github.com/dlclark/regexp2 v1.11.5 // indirect
+	github.com/tdewolff/parse/v2 v2.7.19 // indirect
+	golang.org/x/text v0.23.0 // indirect
 )
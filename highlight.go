@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"draft/site"
+)
+
+/*
+ * Print the stylesheet for highlight.classes:
+ * `draft highlight-css [--style github] [config.yaml] > static/css/highlight.css`
+ *
+ * The style comes from --style, then highlight.style in the config file.
+ */
+func highlightCSS(args []string) {
+	flags := flag.NewFlagSet("highlight-css", flag.ExitOnError)
+	style := flags.String("style", "", "chroma style e.g. github, monokai or dracula")
+	positional := parseArgs(flags, args)
+
+	if *style == "" && len(positional) > 0 {
+		config, err := site.LoadConfig(positional[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+		*style = config.Highlight.Style
+	}
+
+	if err := site.HighlightCSS(os.Stdout, *style); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"draft/site"
+)
+
+/*
+ * Scaffold a post: `draft new "My Title" --tags a,b`
+ */
+func newPost(args []string) {
+	flags := flag.NewFlagSet("new", flag.ExitOnError)
+	configPath := flags.String("config", "config.yaml", "path to config.yaml")
+	tags := flags.String("tags", "", "comma-separated list of tags")
+	templateName := flags.String("template", "default.html", "post template")
+	positional := parseArgs(flags, args)
+	if len(positional) != 1 {
+		fmt.Println(`Usage: draft new "My Title" [--tags a,b] [--template default.html] [--config config.yaml]`)
+		os.Exit(1)
+	}
+
+	config, err := site.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	s, err := site.Load(*config)
+	if err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	var tagList []string
+	if *tags != "" {
+		tagList = strings.Split(*tags, ",")
+	}
+
+	path, err := s.NewPost(positional[0], tagList, *templateName, time.Now())
+	if err != nil {
+		fmt.Printf("Error creating post: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("📝 New post: %s\n", path)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"draft/site"
+)
+
+/*
+ * Local preview server: builds into a temporary directory, serves it on
+ * localhost and rebuilds whenever a post, template or badge changes
+ */
+
+const reloadPath = "/_draft/reload"
+
+const reloadScript = `<script>new EventSource("` + reloadPath + `").onmessage = () => location.reload();</script>`
+
+const watchInterval = 500 * time.Millisecond
+
+type previewServer struct {
+	site    *site.Site
+	prefix  string // URL prefix derived from base_path, e.g. "/draft"
+	mu      sync.RWMutex
+	clients map[chan struct{}]struct{}
+	clMu    sync.Mutex
+}
+
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	port := flags.Int("port", 8080, "port to listen on")
+	jobs := flags.Int("jobs", runtime.NumCPU(), "number of pages to render at once")
+	future := flags.Bool("future", false, "include posts published in the future")
+	drafts := flags.Bool("drafts", false, "render posts with status draft")
+	positional := parseArgs(flags, args)
+	if len(positional) < 1 {
+		fmt.Println("Usage: draft serve [--port 8080] [--jobs N] [--future] [--drafts] [config.yaml]")
+		os.Exit(1)
+	}
+
+	config, err := site.LoadConfig(positional[0])
+	if err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	tempDir, err := os.MkdirTemp("", "draft-serve-")
+	if err != nil {
+		fmt.Printf("Failed to create temporary directory: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(tempDir)
+
+	/*
+	 * Point every generated link at the local server instead of production,
+	 * and keep the preview's build cache apart from the real one
+	 */
+	outputDir := filepath.Join(tempDir, "site")
+	config.OutputDir = outputDir
+	config.CacheDir = filepath.Join(tempDir, "cache")
+	config.URL = fmt.Sprintf("http://localhost:%d", *port)
+	if config.Search.Enabled {
+		config.Search.Path = filepath.Join(outputDir, config.Search.Dir, filepath.Base(config.Search.Path))
+	}
+
+	s, err := site.Load(*config)
+	if err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+		// os.Exit skips the deferred cleanup
+		os.RemoveAll(tempDir)
+		os.Exit(1)
+	}
+	s.Version = Version
+	s.Jobs = *jobs
+	s.Future = *future
+	s.Drafts = *drafts
+
+	server := &previewServer{
+		site:    s,
+		clients: make(map[chan struct{}]struct{}),
+	}
+	if config.BasePath != "" {
+		server.prefix = "/" + strings.Trim(config.BasePath, "/")
+	}
+
+	// Either way out removes the temporary directory
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server.build(ctx)
+
+	go server.watch(ctx, []string{config.InputDir, config.TemplatesDir, config.BadgesDir, config.StaticDir})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(reloadPath, server.handleReload)
+	mux.HandleFunc("/", server.handleFile)
+
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf("localhost:%d", *port),
+		Handler: mux,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("🌐 Serving %s%s/ (Ctrl-C to stop)\n", config.URL, server.prefix)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Printf("Error running server: %v\n", err)
+	}
+}
+
+/*
+ * Rebuild the site. The build cache rewrites only what changed and removes
+ * pages that are no longer produced. Errors are reported but keep the
+ * server running so the writer can fix them.
+ */
+func (s *previewServer) build(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.site.Build(ctx); err != nil {
+		fmt.Printf("❌ Build failed: %v\n", err)
+	}
+}
+
+/*
+ * Poll the source folders and rebuild when anything changes
+ */
+func (s *previewServer) watch(ctx context.Context, dirs []string) {
+	last := snapshot(dirs)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := snapshot(dirs)
+			if sameSnapshot(last, current) {
+				continue
+			}
+			last = current
+			fmt.Printf("🔄 Change detected, rebuilding...\n")
+			s.build(ctx)
+			s.notify()
+		}
+	}
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func snapshot(dirs []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return nil
+			}
+			if info, err := entry.Info(); err == nil {
+				stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+			}
+			return nil
+		})
+	}
+	return stamps
+}
+
+func sameSnapshot(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		if other, ok := b[path]; !ok || !other.modTime.Equal(stamp.modTime) || other.size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ * Live reload: browsers hold open a Server-Sent Events stream and reload
+ * the page when a rebuild finishes
+ */
+
+func (s *previewServer) handleReload(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	ch := make(chan struct{}, 1)
+	s.clMu.Lock()
+	s.clients[ch] = struct{}{}
+	s.clMu.Unlock()
+	defer func() {
+		s.clMu.Lock()
+		delete(s.clients, ch)
+		s.clMu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *previewServer) notify() {
+	s.clMu.Lock()
+	defer s.clMu.Unlock()
+	for ch := range s.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *previewServer) handleFile(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, s.prefix+"/") {
+		if r.URL.Path == "/" || r.URL.Path == s.prefix {
+			http.Redirect(w, r, s.prefix+"/", http.StatusFound)
+		} else {
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rel := path.Clean("/" + strings.TrimPrefix(r.URL.Path, s.prefix))
+	name := filepath.Join(s.site.Config.OutputDir, filepath.FromSlash(rel))
+
+	info, err := os.Stat(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if info.IsDir() {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		name = filepath.Join(name, "index.html")
+	}
+
+	if strings.HasSuffix(name, ".html") {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(injectReloadScript(content))
+		return
+	}
+
+	http.ServeFile(w, r, name)
+}
+
+func injectReloadScript(content []byte) []byte {
+	i := bytes.LastIndex(content, []byte("</body>"))
+	if i < 0 {
+		return append(content, reloadScript...)
+	}
+	var buf bytes.Buffer
+	buf.Write(content[:i])
+	buf.WriteString(reloadScript)
+	buf.Write(content[i:])
+	return buf.Bytes()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"draft/site"
+)
+
+func TestInjectReloadScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{name: "Before body", html: "<html><body>hi</body></html>", expected: "<html><body>hi" + reloadScript + "</body></html>"},
+		{name: "Last body", html: "<body><pre></body></pre></body>", expected: "<body><pre></body></pre>" + reloadScript + "</body>"},
+		{name: "No body", html: "<p>hi</p>", expected: "<p>hi</p>" + reloadScript},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := string(injectReloadScript([]byte(tt.html))); actual != tt.expected {
+				t.Errorf("injectReloadScript() = %q, expected %q", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHandleFile(t *testing.T) {
+	root := t.TempDir()
+	outputDir := filepath.Join(root, "site")
+	files := map[string]string{
+		"site/index.html":       "<html><body>home</body></html>",
+		"site/hello/index.html": "<html><body>hello</body></html>",
+		"site/css/style.css":    "body{color:red}",
+		"secret.txt":            "outside the output folder",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &site.Site{Config: site.Config{OutputDir: outputDir}}
+
+	tests := []struct {
+		name     string
+		prefix   string
+		path     string
+		status   int
+		location string // Expected redirect
+		body     string // Expected content, reload script aside
+	}{
+		{name: "Root", path: "/", status: http.StatusOK, body: "<html><body>home</body></html>"},
+		{name: "Post", path: "/hello/", status: http.StatusOK, body: "<html><body>hello</body></html>"},
+		{name: "Missing slash", path: "/hello", status: http.StatusMovedPermanently, location: "/hello/"},
+		{name: "Static file", path: "/css/style.css", status: http.StatusOK, body: "body{color:red}"},
+		{name: "Missing", path: "/nope/", status: http.StatusNotFound},
+		{name: "Traversal", path: "/../secret.txt", status: http.StatusNotFound},
+		{name: "Prefixed root", prefix: "/draft", path: "/draft/", status: http.StatusOK, body: "<html><body>home</body></html>"},
+		{name: "Prefixed post", prefix: "/draft", path: "/draft/hello/", status: http.StatusOK, body: "<html><body>hello</body></html>"},
+		{name: "Prefixed file", prefix: "/draft", path: "/draft/css/style.css", status: http.StatusOK, body: "body{color:red}"},
+		{name: "Prefixed missing slash", prefix: "/draft", path: "/draft/hello", status: http.StatusMovedPermanently, location: "/draft/hello/"},
+		{name: "Bare root", prefix: "/draft", path: "/", status: http.StatusFound, location: "/draft/"},
+		{name: "Prefix without slash", prefix: "/draft", path: "/draft", status: http.StatusFound, location: "/draft/"},
+		{name: "Outside prefix", prefix: "/draft", path: "/hello/", status: http.StatusNotFound},
+		{name: "Similar prefix", prefix: "/draft", path: "/drafts/hello/", status: http.StatusNotFound},
+		{name: "Prefixed traversal", prefix: "/draft", path: "/draft/../../secret.txt", status: http.StatusNotFound},
+		{name: "Unclean path", prefix: "/draft", path: "/draft//hello/./", status: http.StatusOK, body: "<html><body>hello</body></html>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &previewServer{site: s, prefix: tt.prefix}
+			recorder := httptest.NewRecorder()
+			server.handleFile(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if recorder.Code != tt.status {
+				t.Fatalf("GET %s = %d, expected %d", tt.path, recorder.Code, tt.status)
+			}
+			if location := recorder.Header().Get("Location"); location != tt.location {
+				t.Errorf("GET %s redirected to %q, expected %q", tt.path, location, tt.location)
+			}
+			if tt.body == "" {
+				return
+			}
+			body := recorder.Body.String()
+			if strings.HasSuffix(tt.path, "/") {
+				if !strings.Contains(body, reloadScript) {
+					t.Errorf("GET %s: expected the reload script in %s", tt.path, body)
+				}
+				body = strings.Replace(body, reloadScript, "", 1)
+			}
+			if body != tt.body {
+				t.Errorf("GET %s = %q, expected %q", tt.path, body, tt.body)
+			}
+		})
+	}
+}
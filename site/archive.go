@@ -0,0 +1,155 @@
+package site
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+)
+
+/*
+ * Date-based archive: /archive/ lists every year and month, /archive/2025/
+ * and /archive/2025/01/ list the posts published in that period. Enabled
+ * by setting archive_template_path and archive_page_template_path.
+ */
+
+type ArchivePeriod struct {
+	Name   string // "2025" or "January 2025"
+	Year   int
+	Month  int // 1-12, 0 for a whole year
+	URL    string
+	Count  int             // Number of posts
+	Posts  []Post          // New to old
+	Months []ArchivePeriod // Months of a year with posts, new to old
+}
+
+func archiveEnabled(config Config) bool {
+	return config.ArchiveTemplatePath != "" && config.ArchivePageTemplatePath != ""
+}
+
+/*
+ * Group posts (new to old) by year and month
+ *
+ * returns: years, new to old
+ */
+func buildArchive(config Config, posts []Post) []ArchivePeriod {
+	var years []ArchivePeriod
+	for _, post := range posts {
+		year, month := post.PubTime.Year(), int(post.PubTime.Month())
+		if len(years) == 0 || years[len(years)-1].Year != year {
+			years = append(years, ArchivePeriod{
+				Name: strconv.Itoa(year),
+				Year: year,
+				URL:  buildArchiveLink(config, year, 0),
+			})
+		}
+		y := &years[len(years)-1]
+		if len(y.Months) == 0 || y.Months[len(y.Months)-1].Month != month {
+			y.Months = append(y.Months, ArchivePeriod{
+				Name:  fmt.Sprintf("%s %d", post.PubTime.Month(), year),
+				Year:  year,
+				Month: month,
+				URL:   buildArchiveLink(config, year, month),
+			})
+		}
+		m := &y.Months[len(y.Months)-1]
+		y.Posts = append(y.Posts, post)
+		y.Count++
+		m.Posts = append(m.Posts, post)
+		m.Count++
+	}
+	return years
+}
+
+/*
+ * Every period page: each year followed by its months
+ */
+func archivePeriods(years []ArchivePeriod) []ArchivePeriod {
+	var periods []ArchivePeriod
+	for _, year := range years {
+		periods = append(periods, year)
+		periods = append(periods, year.Months...)
+	}
+	return periods
+}
+
+func archivePath(outputDir string, period ArchivePeriod) string {
+	if period.Month == 0 {
+		return filepath.Join(outputDir, "archive", strconv.Itoa(period.Year), "index.html")
+	}
+	return filepath.Join(outputDir, "archive", strconv.Itoa(period.Year), fmt.Sprintf("%02d", period.Month), "index.html")
+}
+
+func (s *Site) generateArchiveHTML(ctx context.Context, years []ArchivePeriod, posts []Post) error {
+	config := s.Config
+	tmpl, templateKey, err := s.parseTemplate(config.ArchiveTemplatePath)
+	if err != nil {
+		return err
+	}
+
+	archiveFilePath := filepath.Join(config.OutputDir, "archive", "index.html")
+	if !s.unchanged(archiveFilePath, hashKey(s.siteKey, templateKey, postsKey(posts))) {
+		url := s.links.Archive
+		data := map[string]interface{}{
+			"Config": config,
+			"Labels": Labels{Title: config.BlogName + " Archive"},
+			"Years":  years,
+			"Count":  len(posts),
+			"Unfurl": Unfurl{
+				Title:       config.BlogName,
+				URL:         url,
+				Description: config.BlogName + ": Archive",
+				SiteName:    config.BlogName,
+				Locale:      config.Locale,
+			},
+			"Version":   s.Version,
+			"Now":       s.now,
+			"Canonical": url,
+			"Links":     s.links,
+			"Badges":    s.badges,
+		}
+		if err := s.execute(tmpl, archiveFilePath, data); err != nil {
+			return err
+		}
+		s.logf("🗄️  Archive: %s\n", archiveFilePath)
+	}
+
+	pageTemplate, pageKey, err := s.parseTemplate(config.ArchivePageTemplatePath)
+	if err != nil {
+		return err
+	}
+
+	periods := archivePeriods(years)
+	return s.parallel(ctx, len(periods), func(ctx context.Context, i int, out io.Writer) error {
+		period := periods[i]
+		outputFilePath := archivePath(config.OutputDir, period)
+		if s.unchanged(outputFilePath, hashKey(s.siteKey, pageKey, period.URL, postsKey(period.Posts))) {
+			return nil
+		}
+
+		data := map[string]interface{}{
+			"Config": config,
+			"Labels": Labels{Title: config.BlogName + " Archive: " + period.Name},
+			"Period": period,
+			"Posts":  period.Posts,
+			"Unfurl": Unfurl{
+				Title:       config.BlogName,
+				URL:         period.URL,
+				Description: config.BlogName + ": Posts from " + period.Name,
+				SiteName:    config.BlogName,
+				Locale:      config.Locale,
+			},
+			"Version":   s.Version,
+			"Now":       s.now,
+			"Canonical": period.URL,
+			"Links":     s.links,
+			"Badges":    s.badges,
+		}
+		if err := s.execute(pageTemplate, outputFilePath, data); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "🗄️  Archive: %s\n", period.Name)
+		return nil
+	})
+}
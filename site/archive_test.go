@@ -0,0 +1,94 @@
+package site
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildArchive(t *testing.T) {
+	config := Config{URL: "https://example.com"}
+	var posts []Post
+	for _, published := range []string{"2025-02-03T10:00:00Z", "2025-01-20T10:00:00Z", "2025-01-01T10:00:00Z", "2024-12-31T10:00:00Z"} {
+		pubTime, err := time.Parse(time.RFC3339, published)
+		if err != nil {
+			t.Fatal(err)
+		}
+		posts = append(posts, Post{PubTime: pubTime})
+	}
+
+	years := buildArchive(config, posts)
+	if len(years) != 2 || years[0].Name != "2025" || years[0].Count != 3 || years[1].Count != 1 {
+		t.Fatalf("unexpected years %+v", years)
+	}
+	months := years[0].Months
+	if len(months) != 2 || months[0].Name != "February 2025" || months[1].Count != 2 {
+		t.Errorf("unexpected months %+v", months)
+	}
+	if months[1].URL != "https://example.com/archive/2025/01/" || years[1].URL != "https://example.com/archive/2024/" {
+		t.Errorf("unexpected URLs %s, %s", months[1].URL, years[1].URL)
+	}
+	if len(archivePeriods(years)) != 5 {
+		t.Errorf("expected 2 years and 3 months, got %d periods", len(archivePeriods(years)))
+	}
+}
+
+func TestArchivePages(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md":  post("First", "first", "2025-01-01T10:00:00Z", "public"),
+		"20250203.second.md": post("Second", "second", "2025-02-03T10:00:00Z", "public"),
+	})
+	root := filepath.Dir(s.Config.TemplatesDir)
+	s.Config.ArchiveTemplatePath = filepath.Join(root, "templates", "archive.html")
+	s.Config.ArchivePageTemplatePath = filepath.Join(root, "templates", "archive_page.html")
+	os.WriteFile(s.Config.ArchiveTemplatePath, []byte(`{{ range .Years }}{{ .Name }}={{ .Count }} {{ end }}`), 0644)
+	os.WriteFile(s.Config.ArchivePageTemplatePath, []byte(`{{ .Period.Name }}:{{ range .Posts }} {{ .FrontMatter.Link }}{{ end }}`), 0644)
+
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	expected := map[string]string{
+		"archive/index.html":         "2025=2",
+		"archive/2025/index.html":    "2025: second first",
+		"archive/2025/01/index.html": "January 2025: first",
+		"archive/2025/02/index.html": "February 2025: second",
+	}
+	for name, content := range expected {
+		actual, err := os.ReadFile(filepath.Join(s.Config.OutputDir, name))
+		if err != nil || !strings.Contains(string(actual), content) {
+			t.Errorf("%s: expected %q, got %q (%v)", name, content, actual, err)
+		}
+	}
+
+	sitemap, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "sitemap.xml"))
+	if !strings.Contains(string(sitemap), "https://example.com/archive/2025/02/") {
+		t.Errorf("expected archive pages in sitemap")
+	}
+}
+
+func TestArchiveLinkClash(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md": post("First", "archive", "2025-01-01T10:00:00Z", "public"),
+	})
+	s.Config.ArchiveTemplatePath = filepath.Join(s.Config.TemplatesDir, "index.html")
+	s.Config.ArchivePageTemplatePath = filepath.Join(s.Config.TemplatesDir, "index.html")
+
+	_, err := s.Build(context.Background())
+	var target *DuplicateLinkError
+	if !errors.As(err, &target) || target.Link != "archive" {
+		t.Errorf("Build() = %v, expected a DuplicateLinkError for archive", err)
+	}
+
+	report, err := s.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check() = %v", err)
+	}
+	if len(report.Problems) != 1 || !strings.Contains(report.Problems[0].Message, "duplicate link archive") {
+		t.Errorf("expected the clash with /archive/ to be reported, got %v", report.Problems)
+	}
+}
@@ -0,0 +1,115 @@
+package site
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+/*
+ * Page bundles: a post may be a folder, e.g. 20250101.slug/index.md, with
+ * images and other files beside the Markdown. Those files are copied into
+ * the post's output folder so relative links in the post just work.
+ */
+
+const bundleIndex = "index.md"
+
+/*
+ * returns: path of the Markdown behind an entry in input_dir, empty if the
+ * entry is a folder without an index.md
+ */
+func postSource(inputDir string, file fs.DirEntry) string {
+	filePath := filepath.Join(inputDir, file.Name())
+	if !file.IsDir() {
+		return filePath
+	}
+	indexPath := filepath.Join(filePath, bundleIndex)
+	if info, err := os.Stat(indexPath); err != nil || info.IsDir() {
+		return ""
+	}
+	return indexPath
+}
+
+/*
+ * Resolve a possibly relative URL against the page it appears on
+ */
+func resolveURL(ref string, base string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil || refURL.IsAbs() || ref == "" || ref[0] == '#' {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+/*
+ * Copy every file in a post's bundle, other than its index.md and images
+ * with variants, into the post's output folder
+ */
+func (s *Site) copyBundle(post Post, out io.Writer) error {
+	if post.bundle == "" {
+		return nil
+	}
+	outputDir := filepath.Join(s.Config.OutputDir, post.FrontMatter.Link)
+
+	return filepath.WalkDir(post.bundle, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return &PostError{Path: post.path, Err: err}
+		}
+		if entry.IsDir() || path == post.path {
+			return nil
+		}
+		if _, ok := s.images[path]; ok {
+			return nil
+		}
+		rel, err := filepath.Rel(post.bundle, path)
+		if err != nil {
+			return &PostError{Path: post.path, Err: err}
+		}
+		if rel == "index.html" {
+			return &PostError{Path: post.path, Err: fmt.Errorf("bundle file %s would overwrite the rendered post", rel)}
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return &PostError{Path: post.path, Err: err}
+		}
+		outputPath := filepath.Join(outputDir, rel)
+		if s.unchanged(outputPath, hashKey(path, fmt.Sprint(info.Size()), info.ModTime().String())) {
+			return nil
+		}
+		if err := s.copyFile(path, outputPath); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "📎 Asset: %s/%s\n", post.FrontMatter.Link, filepath.ToSlash(rel))
+		return nil
+	})
+}
+
+func (s *Site) copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open file '%s': %w", src, err)
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return &WriteError{Path: filepath.Dir(dst), Err: err}
+	}
+	out, err := s.create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return &WriteError{Path: dst, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package site
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{ref: "photo.jpg", expected: "https://example.com/hello/photo.jpg"},
+		{ref: "./images/photo.jpg", expected: "https://example.com/hello/images/photo.jpg"},
+		{ref: "/photo.jpg", expected: "https://example.com/photo.jpg"},
+		{ref: "https://cdn.example.com/photo.jpg", expected: "https://cdn.example.com/photo.jpg"},
+		{ref: "#top", expected: "#top"},
+		{ref: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if actual := resolveURL(tt.ref, "https://example.com/hello/"); actual != tt.expected {
+				t.Errorf("resolveURL(%q) = %q, expected %q", tt.ref, actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPageBundle(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md": post("First", "first", "2025-01-01T10:00:00Z", "public"),
+	})
+
+	bundle := filepath.Join(s.Config.InputDir, "20250102.bundled")
+	withImage := strings.Replace(post("Bundled", "bundled", "2025-01-02T10:00:00Z", "public"), "status: public\n", "status: public\nimage: cover.jpg\n", 1)
+	files := map[string]string{
+		"index.md":         withImage + "\n![Photo](images/photo.jpg)\n",
+		"cover.jpg":        "cover",
+		"images/photo.jpg": "photo",
+	}
+	for name, content := range files {
+		path := filepath.Join(bundle, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A folder without an index.md is not a post
+	if err := os.MkdirAll(filepath.Join(s.Config.InputDir, "notes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.Build(context.Background())
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if len(result.Posts) != 2 || result.Posts[0].FrontMatter.Link != "bundled" {
+		t.Fatalf("expected the bundle to be loaded as a post, got %d posts", len(result.Posts))
+	}
+	if result.Posts[0].Image != "https://example.com/bundled/cover.jpg" {
+		t.Errorf("expected an absolute image URL, got %q", result.Posts[0].Image)
+	}
+
+	for name, content := range map[string]string{"cover.jpg": "cover", "images/photo.jpg": "photo"} {
+		actual, err := os.ReadFile(filepath.Join(s.Config.OutputDir, "bundled", name))
+		if err != nil || string(actual) != content {
+			t.Errorf("%s: expected asset to be copied, got %q, %v", name, actual, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(s.Config.OutputDir, "bundled", "index.md")); !os.IsNotExist(err) {
+		t.Errorf("index.md should not be copied")
+	}
+
+	feed, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "feed.json"))
+	if !strings.Contains(string(feed), `"image": "https://example.com/bundled/cover.jpg"`) {
+		t.Errorf("expected an absolute image in feed.json: %s", feed)
+	}
+
+	// Assets that disappear from the bundle are removed from the output
+	if err := os.Remove(filepath.Join(bundle, "cover.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Config.OutputDir, "bundled", "cover.jpg")); !os.IsNotExist(err) {
+		t.Errorf("expected removed asset to be pruned")
+	}
+}
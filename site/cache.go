@@ -0,0 +1,281 @@
+package site
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+ * Incremental builds: a manifest in the cache folder remembers a hash of
+ * the inputs behind every output file. Outputs whose inputs are unchanged
+ * are left alone and outputs that are no longer produced are removed.
+ */
+
+const cacheVersion = "3"
+
+const defaultCacheDir = ".draft-cache"
+
+type cacheManifest struct {
+	Version   string
+	OutputDir string
+	Outputs   map[string]string     // Output path relative to OutputDir => input hash
+	Posts     map[string]cachedPost // Source hash => content derived from it
+}
+
+type cachedPost struct {
+	Text      string // Plaintext representation
+	WordCount int    // See reading.go
+	Excerpt   string
+	HTML      string // Rendered Markdown
+	TOC       TOC    // Table of contents, see toc.go
+}
+
+type buildCache struct {
+	mu        sync.Mutex // Guards next while workers render
+	path      string
+	outputDir string
+	old       cacheManifest
+	next      cacheManifest
+	stale     map[string]bool // Outputs this build is rewriting
+}
+
+func newManifest(outputDir string) cacheManifest {
+	return cacheManifest{
+		Version:   cacheVersion,
+		OutputDir: outputDir,
+		Outputs:   make(map[string]string),
+		Posts:     make(map[string]cachedPost),
+	}
+}
+
+/*
+ * Read the manifest left behind by the previous build. A missing, stale or
+ * unreadable manifest, or ignore = true, simply means nothing is reused.
+ */
+func loadCache(cacheDir, outputDir string, ignore bool) *buildCache {
+	if cacheDir == "" {
+		cacheDir = defaultCacheDir
+	}
+	if abs, err := filepath.Abs(outputDir); err == nil {
+		outputDir = abs
+	}
+
+	cache := &buildCache{
+		path:      filepath.Join(cacheDir, "manifest.json"),
+		outputDir: outputDir,
+		old:       newManifest(outputDir),
+		next:      newManifest(outputDir),
+		stale:     make(map[string]bool),
+	}
+	if ignore {
+		return cache
+	}
+
+	content, err := os.ReadFile(cache.path)
+	if err != nil {
+		return cache
+	}
+	var old cacheManifest
+	if err := json.Unmarshal(content, &old); err != nil || old.Version != cacheVersion || old.OutputDir != outputDir {
+		return cache
+	}
+	if old.Outputs != nil {
+		cache.old.Outputs = old.Outputs
+	}
+	if old.Posts != nil {
+		cache.old.Posts = old.Posts
+	}
+	return cache
+}
+
+func (c *buildCache) relative(outputPath string) string {
+	abs, err := filepath.Abs(outputPath)
+	if err != nil {
+		return outputPath
+	}
+	if rel, err := filepath.Rel(c.outputDir, abs); err == nil {
+		return rel
+	}
+	return abs
+}
+
+/*
+ * Record the inputs behind an output and report whether the copy on disk
+ * was produced from the same inputs
+ */
+func (c *buildCache) unchanged(outputPath, key string) bool {
+	rel := c.relative(outputPath)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.next.Outputs[rel] = key
+	if c.old.Outputs[rel] == key {
+		if _, err := os.Stat(outputPath); err == nil {
+			return true
+		}
+	}
+	c.stale[rel] = true
+	return false
+}
+
+/*
+ * Report whether this build has already produced an output
+ */
+func (c *buildCache) produced(outputPath string) bool {
+	rel := c.relative(outputPath)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.next.Outputs[rel]
+	return ok
+}
+
+func (c *buildCache) post(hash string) (cachedPost, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.next.Posts[hash]; ok {
+		return entry, true
+	}
+	entry, ok := c.old.Posts[hash]
+	return entry, ok
+}
+
+func (c *buildCache) setPost(hash string, entry cachedPost) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.next.Posts[hash] = entry
+}
+
+/*
+ * Delete outputs written by a previous build that this build no longer
+ * produces, e.g. a post that was renamed or made private
+ *
+ * returns: paths removed
+ */
+func (c *buildCache) prune() []string {
+	var removed []string
+	for rel := range c.old.Outputs {
+		if _, ok := c.next.Outputs[rel]; ok {
+			continue
+		}
+		path := rel
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.outputDir, rel)
+		}
+		if err := os.Remove(path); err != nil {
+			continue
+		}
+		removed = append(removed, path)
+
+		// Tidy up folders left empty, e.g. /old-link/
+		for dir := filepath.Dir(path); dir != c.outputDir && strings.HasPrefix(dir, c.outputDir); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+func (c *buildCache) save() error {
+	return c.write(c.next)
+}
+
+/*
+ * Save what is still true after a failed build: the previous outputs this
+ * build never got round to rewriting. Outputs it did rewrite, or was about
+ * to, are forgotten so the next build writes them again.
+ */
+func (c *buildCache) saveFailed() error {
+	c.mu.Lock()
+	partial := newManifest(c.outputDir)
+	for rel, key := range c.old.Outputs {
+		if !c.stale[rel] {
+			partial.Outputs[rel] = key
+		}
+	}
+	maps.Copy(partial.Posts, c.old.Posts)
+	maps.Copy(partial.Posts, c.next.Posts)
+	c.mu.Unlock()
+	return c.write(partial)
+}
+
+func (c *buildCache) write(manifest cacheManifest) error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return &WriteError{Path: filepath.Dir(c.path), Err: err}
+	}
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return &WriteError{Path: c.path, Err: err}
+	}
+	if err := os.WriteFile(c.path, content, 0644); err != nil {
+		return &WriteError{Path: c.path, Err: err}
+	}
+	return nil
+}
+
+/*
+ * Hash any number of inputs into a cache key
+ */
+func hashKey(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		io.WriteString(h, part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+/*
+ * Combined hash of a list of posts, order included
+ */
+func postsKey(posts []Post) string {
+	hashes := make([]string, len(posts))
+	for i, post := range posts {
+		hashes[i] = post.hash
+	}
+	return hashKey(hashes...)
+}
+
+/*
+ * Hash of everything that affects every page: config, version and badges
+ */
+func siteKey(config Config, version string, badges map[string]template.HTML) (string, error) {
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	parts := []string{cacheVersion, string(configJSON), version}
+	names := make([]string, 0, len(badges))
+	for name := range badges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, name, string(badges[name]))
+	}
+	return hashKey(parts...), nil
+}
+
+/*
+ * Hash the files that make up a template
+ */
+func templateKey(paths ...string) (string, error) {
+	parts := make([]string, len(paths))
+	for i, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = string(content)
+	}
+	return hashKey(parts...), nil
+}
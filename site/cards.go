@@ -0,0 +1,319 @@
+package site
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+/*
+ * Social cards: a PNG per post showing its title, favicon and date with the
+ * blog's name, used as og:image for posts that have no `image` of their
+ * own. Drawn in pure Go with the Go fonts unless others are configured.
+ */
+
+type SocialCardsConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Width      int    `yaml:"width"`       // Pixels, defaults to 1200
+	Height     int    `yaml:"height"`      // Pixels, defaults to 630
+	Padding    int    `yaml:"padding"`     // Pixels around the edge, defaults to 80
+	TitleSize  int    `yaml:"title_size"`  // Points, defaults to 64
+	FooterSize int    `yaml:"footer_size"` // Points of the blog name and date, defaults to 32
+	Background string `yaml:"background"`  // #rrggbb, defaults to #1e293b
+	Foreground string `yaml:"foreground"`  // #rrggbb, defaults to #f8fafc
+	Accent     string `yaml:"accent"`      // #rrggbb of the top bar and date, defaults to #38bdf8
+	TitleFont  string `yaml:"title_font"`  // TrueType or OpenType file, defaults to Go Bold
+	TextFont   string `yaml:"text_font"`   // Defaults to Go Regular
+	EmojiFont  string `yaml:"emoji_font"`  // Monochrome font with glyphs for favicons, which are left out without one
+}
+
+const socialCardName = "card.png"
+
+/*
+ * Configured layout with the defaults filled in and the fonts parsed
+ */
+type cardLayout struct {
+	config     SocialCardsConfig
+	background color.Color
+	foreground color.Color
+	accent     color.Color
+	titleFont  *opentype.Font
+	textFont   *opentype.Font
+	emojiFont  *opentype.Font // nil unless configured
+	key        string         // Hash of the settings and font files
+}
+
+func loadCardLayout(config SocialCardsConfig) (*cardLayout, error) {
+	setDefault := func(value *int, fallback int) {
+		if *value == 0 {
+			*value = fallback
+		}
+	}
+	setDefault(&config.Width, 1200)
+	setDefault(&config.Height, 630)
+	setDefault(&config.Padding, 80)
+	setDefault(&config.TitleSize, 64)
+	setDefault(&config.FooterSize, 32)
+	if config.Width < 0 || config.Height < 0 || config.Padding < 0 || config.TitleSize < 0 || config.FooterSize < 0 || 2*config.Padding >= min(config.Width, config.Height) {
+		return nil, fmt.Errorf("social_cards: sizes must be positive and padding must leave room for text")
+	}
+
+	layout := &cardLayout{config: config}
+	keys := []string{fmt.Sprintf("%#v", config)}
+	var err error
+	colors := []struct {
+		name     string
+		value    string
+		fallback string
+		target   *color.Color
+	}{
+		{"background", config.Background, "#1e293b", &layout.background},
+		{"foreground", config.Foreground, "#f8fafc", &layout.foreground},
+		{"accent", config.Accent, "#38bdf8", &layout.accent},
+	}
+	for _, c := range colors {
+		value := c.value
+		if value == "" {
+			value = c.fallback
+		}
+		if *c.target, err = parseHexColor(value); err != nil {
+			return nil, fmt.Errorf("social_cards.%s: %w", c.name, err)
+		}
+	}
+
+	fonts := []struct {
+		name     string
+		path     string
+		fallback []byte
+		target   **opentype.Font
+	}{
+		{"title_font", config.TitleFont, gobold.TTF, &layout.titleFont},
+		{"text_font", config.TextFont, goregular.TTF, &layout.textFont},
+		{"emoji_font", config.EmojiFont, nil, &layout.emojiFont},
+	}
+	for _, f := range fonts {
+		content := f.fallback
+		if f.path != "" {
+			if content, err = os.ReadFile(f.path); err != nil {
+				return nil, fmt.Errorf("social_cards.%s: %w", f.name, err)
+			}
+			keys = append(keys, string(content))
+		}
+		if content == nil {
+			continue
+		}
+		if *f.target, err = opentype.Parse(content); err != nil {
+			return nil, fmt.Errorf("social_cards.%s: %w", f.name, err)
+		}
+	}
+	layout.key = hashKey(keys...)
+	return layout, nil
+}
+
+func parseHexColor(value string) (color.Color, error) {
+	hex := strings.TrimPrefix(value, "#")
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return nil, fmt.Errorf("invalid color %s, expected #rrggbb", value)
+	}
+	return color.RGBA{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n), A: 255}, nil
+}
+
+/*
+ * Write a post's card unless it is up to date
+ *
+ * returns: URL of the card, empty if the post has its own image or cards
+ * are disabled
+ */
+func (s *Site) generateSocialCard(post Post, out io.Writer) (string, error) {
+	if s.cards == nil || post.FrontMatter.Image != "" {
+		return "", nil
+	}
+	config := s.Config
+	if favicon := post.FrontMatter.Favicon; favicon != "" && !s.cards.canDraw(favicon) {
+		fmt.Fprintf(out, "⚠️  Card: %s has no glyph for favicon %s in emoji_font, leaving it off...\n", post.FrontMatter.Link, favicon)
+	}
+	outputPath := filepath.Join(config.OutputDir, post.FrontMatter.Link, socialCardName)
+	url := post.URL + socialCardName
+	date := post.PubTime.Format("January 2, 2006")
+	if s.unchanged(outputPath, hashKey(s.cards.key, post.FrontMatter.Title, post.FrontMatter.Favicon, config.BlogName, date)) {
+		return url, nil
+	}
+
+	card, err := s.cards.draw(post.FrontMatter.Title, post.FrontMatter.Favicon, config.BlogName, date)
+	if err != nil {
+		return "", &PostError{Path: post.path, Err: fmt.Errorf("failed to draw social card: %w", err)}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return "", &WriteError{Path: filepath.Dir(outputPath), Err: err}
+	}
+	file, err := s.create(outputPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if err := png.Encode(file, card); err != nil {
+		return "", &WriteError{Path: outputPath, Err: err}
+	}
+	return url, nil
+}
+
+/*
+ * Accent bar along the top, favicon and title below it, blog name and date
+ * along the bottom
+ */
+func (l *cardLayout) draw(title, favicon, blogName, date string) (image.Image, error) {
+	config := l.config
+	card := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	draw.Draw(card, card.Bounds(), image.NewUniform(l.background), image.Point{}, draw.Src)
+	draw.Draw(card, image.Rect(0, 0, config.Width, config.Padding/6), image.NewUniform(l.accent), image.Point{}, draw.Src)
+
+	newFace := func(f *opentype.Font, size int) (font.Face, error) {
+		return opentype.NewFace(f, &opentype.FaceOptions{Size: float64(size), DPI: 72, Hinting: font.HintingFull})
+	}
+	titleFace, err := newFace(l.titleFont, config.TitleSize)
+	if err != nil {
+		return nil, err
+	}
+	defer titleFace.Close()
+	textFace, err := newFace(l.textFont, config.FooterSize)
+	if err != nil {
+		return nil, err
+	}
+	defer textFace.Close()
+
+	width := config.Width - 2*config.Padding
+	y := config.Padding
+
+	/*
+	 * Favicon, when there's a font that has it
+	 */
+	if favicon != "" && l.canDraw(favicon) {
+		emojiFace, err := newFace(l.emojiFont, config.TitleSize)
+		if err != nil {
+			return nil, err
+		}
+		defer emojiFace.Close()
+		y += emojiFace.Metrics().Ascent.Ceil()
+		drawText(card, emojiFace, l.foreground, config.Padding, y, favicon)
+		y += emojiFace.Metrics().Descent.Ceil() + config.TitleSize/2
+	}
+
+	/*
+	 * Title, wrapped to fit above the footer
+	 */
+	footerTop := config.Height - config.Padding - textFace.Metrics().Height.Ceil()
+	lineHeight := titleFace.Metrics().Height.Ceil() * 6 / 5
+	maxLines := max(1, (footerTop-y-config.TitleSize/2)/lineHeight)
+	for _, line := range wrapText(titleFace, title, width, maxLines) {
+		y += lineHeight
+		drawText(card, titleFace, l.foreground, config.Padding, y, line)
+	}
+
+	/*
+	 * Footer
+	 */
+	baseline := config.Height - config.Padding
+	dateWidth := font.MeasureString(textFace, date).Ceil()
+	drawText(card, textFace, l.foreground, config.Padding, baseline, truncateText(textFace, blogName, width-dateWidth-config.FooterSize))
+	drawText(card, textFace, l.accent, config.Width-config.Padding-dateWidth, baseline, date)
+	return card, nil
+}
+
+func drawText(dst draw.Image, face font.Face, c color.Color, x, y int, text string) {
+	drawer := &font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+	drawer.DrawString(text)
+}
+
+/*
+ * Report whether the emoji font has an outline for every character of a
+ * favicon. Colour fonts keep their emoji as bitmaps (CBDT) or layers
+ * (COLR), which can't be drawn here.
+ */
+func (l *cardLayout) canDraw(favicon string) bool {
+	if l.emojiFont == nil {
+		return false
+	}
+	var buf sfnt.Buffer
+	for _, r := range favicon {
+		// Variation selectors and joiners have no glyph of their own
+		if r == 0xfe0f || r == 0x200d {
+			continue
+		}
+		index, err := l.emojiFont.GlyphIndex(&buf, r)
+		if err != nil || index == 0 {
+			return false
+		}
+		segments, err := l.emojiFont.LoadGlyph(&buf, index, fixed.I(l.config.TitleSize), nil)
+		if err != nil || len(segments) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ * Break text into lines no wider than width, ending the last line with an
+ * ellipsis if there are more words than lines
+ */
+func wrapText(face font.Face, text string, width, maxLines int) []string {
+	var lines []string
+	line := ""
+	words := strings.Fields(text)
+	for i, word := range words {
+		candidate := strings.TrimSpace(line + " " + word)
+		if line != "" && font.MeasureString(face, candidate).Ceil() > width {
+			if len(lines) == maxLines-1 {
+				return append(lines, truncateText(face, strings.Join(append([]string{line}, words[i:]...), " "), width))
+			}
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, truncateText(face, line, width))
+	}
+	return lines
+}
+
+/*
+ * Shorten text with an ellipsis until it fits in width, dropping whole
+ * words where possible
+ */
+func truncateText(face font.Face, text string, width int) string {
+	if font.MeasureString(face, text).Ceil() <= width {
+		return text
+	}
+	words := strings.Fields(text)
+	for n := len(words) - 1; n > 0; n-- {
+		candidate := strings.Join(words[:n], " ") + "…"
+		if font.MeasureString(face, candidate).Ceil() <= width {
+			return candidate
+		}
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimSpace(string(runes)) + "…"
+		if font.MeasureString(face, candidate).Ceil() <= width {
+			return candidate
+		}
+	}
+	return ""
+}
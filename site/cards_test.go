@@ -0,0 +1,150 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+func TestWrapText(t *testing.T) {
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: 10, DPI: 72})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer face.Close()
+	width := font.MeasureString(face, "Hello World").Ceil()
+
+	tests := []struct {
+		name     string
+		text     string
+		maxLines int
+		expected []string
+	}{
+		{name: "Fits", text: "Hello World", maxLines: 3, expected: []string{"Hello World"}},
+		{name: "Wraps", text: "Hello World Hello World", maxLines: 3, expected: []string{"Hello World", "Hello World"}},
+		{name: "Ellipsis", text: "Hello World Hello World Hello", maxLines: 2, expected: []string{"Hello World", "Hello…"}},
+		{name: "Long word", text: "Supercalifragilisticexpialidocious", maxLines: 2, expected: []string{"Supercali…"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := wrapText(face, tt.text, width, tt.maxLines)
+			if strings.Join(actual, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("wrapText() = %q, expected %q", actual, tt.expected)
+			}
+			for _, line := range actual {
+				if font.MeasureString(face, line).Ceil() > width {
+					t.Errorf("line %q is wider than %d", line, width)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadCardLayout(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      SocialCardsConfig
+		expectError bool
+	}{
+		{name: "Defaults", config: SocialCardsConfig{Enabled: true}},
+		{name: "Colors", config: SocialCardsConfig{Background: "#000000", Foreground: "ffffff"}},
+		{name: "Bad color", config: SocialCardsConfig{Accent: "blue"}, expectError: true},
+		{name: "Padding too large", config: SocialCardsConfig{Width: 200, Height: 100, Padding: 50}, expectError: true},
+		{name: "Missing font", config: SocialCardsConfig{TitleFont: "/nonexistent.ttf"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadCardLayout(tt.config)
+			if (err != nil) != tt.expectError {
+				t.Errorf("loadCardLayout() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestCanDraw(t *testing.T) {
+	goFont, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		font     *opentype.Font
+		favicon  string
+		expected bool
+	}{
+		{name: "No emoji font", favicon: "🚀", expected: false},
+		{name: "Has glyph", font: goFont, favicon: "A", expected: true},
+		{name: "Variation selector", font: goFont, favicon: "A\ufe0f", expected: true},
+		{name: "Missing glyph", font: goFont, favicon: "🚀", expected: false},
+		{name: "Glyph without outline", font: goFont, favicon: " ", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := &cardLayout{config: SocialCardsConfig{TitleSize: 64}, emojiFont: tt.font}
+			if actual := layout.canDraw(tt.favicon); actual != tt.expected {
+				t.Errorf("canDraw(%q) = %v, expected %v", tt.favicon, actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSocialCards(t *testing.T) {
+	withImage := strings.Replace(post("Second", "second", "2025-01-02T10:00:00Z", "public"), "status: public\n", "status: public\nimage: https://cdn.example.com/x.jpg\n", 1)
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md":  strings.Replace(post("A Rather Long Title That Needs To Wrap Onto Another Line", "first", "2025-01-01T10:00:00Z", "public"), "status: public\n", "status: public\nfavicon: 🚀\n", 1),
+		"20250102.second.md": withImage,
+	})
+	s.Config.SocialCards = SocialCardsConfig{Enabled: true}
+	if err := os.WriteFile(filepath.Join(s.Config.TemplatesDir, "default.html"), []byte(`{{ .Unfurl.Image }}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var log bytes.Buffer
+	s.Log = &log
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if !strings.Contains(log.String(), "⚠️  Card: first has no glyph for favicon 🚀") {
+		t.Errorf("expected a warning for the favicon the Go fonts lack, got %s", log.String())
+	}
+
+	file, err := os.Open(filepath.Join(s.Config.OutputDir, "first", "card.png"))
+	if err != nil {
+		t.Fatalf("expected a card: %v", err)
+	}
+	defer file.Close()
+	card, err := png.DecodeConfig(file)
+	if err != nil || card.Width != 1200 || card.Height != 630 {
+		t.Errorf("expected a 1200x630 PNG, got %+v, %v", card, err)
+	}
+
+	expected := map[string]string{
+		"first":  "https://example.com/first/card.png",
+		"second": "https://cdn.example.com/x.jpg",
+	}
+	for link, image := range expected {
+		page, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, link, "index.html"))
+		if string(page) != image {
+			t.Errorf("%s: expected Unfurl.Image %s, got %s", link, image, page)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(s.Config.OutputDir, "second", "card.png")); !os.IsNotExist(err) {
+		t.Errorf("posts with an image should not get a card")
+	}
+}
@@ -0,0 +1,368 @@
+package site
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+ * Lint the whole site without writing anything: every post is parsed and
+ * validated and every problem is collected rather than stopping at the
+ * first one
+ */
+
+type Problem struct {
+	File    string `json:"file"`           // Empty for problems in the config file
+	Line    int    `json:"line,omitempty"` // 1-based, 0 when unknown
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	switch {
+	case p.File == "":
+		return fmt.Sprintf("config: %s", p.Message)
+	case p.Line == 0:
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	default:
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+	}
+}
+
+type CheckReport struct {
+	Posts    int       `json:"posts"`    // Number of post files examined
+	Problems []Problem `json:"problems"` // Sorted by file and line
+	Warnings []Problem `json:"warnings"` // What the build would skip with a warning, e.g. related posts without related.strict
+}
+
+/*
+ * A post as seen by the checker: its front matter plus the file line of
+ * each header so problems can point at them
+ */
+type checkedPost struct {
+	path        string
+	name        string // Entry in input_dir: the file, or the folder of a bundle
+	frontMatter FrontMatter
+	start       int            // Line of the opening ---
+	lines       map[string]int // Header name => line
+	related     map[int]int    // Index in related => line
+	fences      map[int]string // Line => info string of fenced code blocks
+	status      PostStatus
+}
+
+var yamlLine = regexp.MustCompile(`line (\d+)`)
+
+/*
+ * Check every post, page and template. The error is only set when the
+ * check itself could not run, e.g. the input folder is missing.
+ */
+func (s *Site) Check(ctx context.Context) (*CheckReport, error) {
+	config := s.Config
+	report := &CheckReport{Problems: []Problem{}, Warnings: []Problem{}}
+	add := func(file string, line int, format string, args ...interface{}) {
+		report.Problems = append(report.Problems, Problem{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(file string, line int, format string, args ...interface{}) {
+		report.Warnings = append(report.Warnings, Problem{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	files, err := os.ReadDir(config.InputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory '%s': %w", config.InputDir, err)
+	}
+
+	/*
+	 * Templates named in the config file
+	 */
+	checkedTemplates := make(map[string]bool)
+	checkTemplate := func(templatePath string) error {
+		if _, ok := checkedTemplates[templatePath]; !ok {
+			_, err := s.parseTemplateFiles(templatePath, filepath.Join(config.TemplatesDir, "shared.html"))
+			checkedTemplates[templatePath] = err == nil
+			return err
+		}
+		if !checkedTemplates[templatePath] {
+			return fmt.Errorf("template %s failed to parse", templatePath)
+		}
+		return nil
+	}
+	templatePaths := []string{config.IndexTemplatePath, config.TagsIndexTemplatePath, config.TagPageTemplatePath}
+	if archiveEnabled(config) {
+		templatePaths = append(templatePaths, config.ArchiveTemplatePath, config.ArchivePageTemplatePath)
+	}
+	for _, templatePath := range templatePaths {
+		if err := checkTemplate(templatePath); err != nil {
+			add("", 0, "%v", err)
+		}
+	}
+	for _, page := range config.Pages {
+		if err := validateLinkName(page.Link); err != nil {
+			add("", 0, "page %s: %v", page.Title, err)
+		}
+		if err := checkTemplate(filepath.Join(config.TemplatesDir, page.Template)); err != nil {
+			add("", 0, "page %s: %v", page.Title, err)
+		}
+	}
+
+	tags, err := newTagSet(config)
+	if err != nil {
+		var configErr *ConfigError
+		if errors.As(err, &configErr) {
+			err = configErr.Err
+		}
+		add("", 0, "%v", err)
+		withoutAliases := config
+		withoutAliases.TagAliases = nil
+		tags, _ = newTagSet(withoutAliases)
+	}
+
+	/*
+	 * Each post on its own
+	 */
+	var posts []checkedPost
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		filePath := postSource(config.InputDir, file)
+		if filePath == "" {
+			continue
+		}
+		report.Posts++
+
+		post, err := readCheckedPost(filePath)
+		post.name = file.Name()
+		if err != nil {
+			line := 0
+			if match := yamlLine.FindStringSubmatch(err.Error()); match != nil && post.start > 0 {
+				n, _ := strconv.Atoi(match[1])
+				line = post.start + n
+			}
+			// Drop the YAML dump parseFileWithHeaders appends
+			message, _, _ := strings.Cut(err.Error(), "\n")
+			add(filePath, line, "%s", message)
+			continue
+		}
+		frontMatter := post.frontMatter
+
+		if err := validateHeaders(frontMatter, filePath); err != nil {
+			for _, problem := range err.(*ValidationError).Problems {
+				line := post.start
+				// Point at the header named in "Invalid value for status: bogus"
+				if rest, ok := strings.CutPrefix(problem, "Invalid value for "); ok {
+					key, _, _ := strings.Cut(rest, ":")
+					if post.lines[key] > 0 {
+						line = post.lines[key]
+					}
+				}
+				add(filePath, line, "%s", problem)
+			}
+		}
+
+		if frontMatter.Link != "" {
+			if err := validateLinkName(frontMatter.Link); err != nil {
+				add(filePath, post.lines["link"], "%v", err)
+			}
+		}
+
+		for _, name := range frontMatter.Tags {
+			if strings.TrimSpace(name) == "" {
+				continue
+			}
+			if _, err := tags.tag(name); err != nil {
+				add(filePath, post.lines["tags"], "%v", err)
+			}
+		}
+
+		if frontMatter.Published != "" {
+			if _, err := time.Parse(time.RFC3339, frontMatter.Published); err != nil {
+				add(filePath, post.lines["published"], "error parsing date: %v", err)
+			}
+		}
+
+		if frontMatter.Updated != "" {
+			if _, err := time.Parse(time.RFC3339, frontMatter.Updated); err != nil {
+				add(filePath, post.lines["updated"], "error parsing updated date: %v", err)
+			}
+		}
+
+		if frontMatter.Template != "" {
+			if err := checkTemplate(filepath.Join(config.TemplatesDir, frontMatter.Template)); err != nil {
+				add(filePath, post.lines["template"], "%v", err)
+			}
+		}
+
+		if config.Highlight.Enabled {
+			for line, info := range post.fences {
+				if _, err := parseFenceInfo(info, false); err != nil {
+					add(filePath, line, "code block: %v", err)
+				}
+			}
+		}
+
+		posts = append(posts, post)
+	}
+
+	/*
+	 * Across posts: duplicate links and related links
+	 */
+	byLink := make(map[string]checkedPost)
+	reserved := reservedLinks(config)
+	for _, page := range config.Pages {
+		if reserved[page.Link] {
+			add("", 0, "page %s: duplicate link %s: also used by a generated page", page.Title, page.Link)
+		} else if _, ok := byLink[page.Link]; ok {
+			add("", 0, "page %s: duplicate link %s: also used by another page", page.Title, page.Link)
+		}
+		byLink[page.Link] = checkedPost{}
+	}
+	for _, post := range posts {
+		link := post.frontMatter.Link
+		if link == "" {
+			continue
+		}
+		if reserved[link] {
+			add(post.path, post.lines["link"], "duplicate link %s: also used by a generated page", link)
+			continue
+		}
+		if other, ok := byLink[link]; ok {
+			if other.path == "" {
+				add(post.path, post.lines["link"], "duplicate link %s: also used by a page", link)
+			} else {
+				add(post.path, post.lines["link"], "duplicate link %s: also used by %s", link, other.path)
+			}
+			continue
+		}
+		byLink[link] = post
+	}
+
+	/*
+	 * Related links the way the build resolves them: only for posts it
+	 * renders, against posts that are public and not scheduled, failing
+	 * only with related.strict
+	 */
+	now := time.Now()
+	scheduled := func(post checkedPost) bool {
+		published, err := time.Parse(time.RFC3339, post.frontMatter.Published)
+		return err == nil && !s.Future && published.After(now)
+	}
+	relatedIndex := newRelatedIndex()
+	for _, post := range posts {
+		relatedIndex.add(post.name, post.frontMatter.Link, post.status != Public || scheduled(post))
+	}
+	for _, post := range posts {
+		if post.status == Private || (post.status == Draft && !s.Drafts) || scheduled(post) {
+			continue
+		}
+		for i, ref := range post.frontMatter.Related {
+			if _, err := relatedIndex.resolve(ref); err != nil {
+				if config.Related.Strict {
+					add(post.path, post.related[i], "%v", err)
+				} else {
+					warn(post.path, post.related[i], "%v", err)
+				}
+			}
+		}
+	}
+
+	for _, problems := range [][]Problem{report.Problems, report.Warnings} {
+		sort.SliceStable(problems, func(i, j int) bool {
+			a, b := problems[i], problems[j]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			return a.Line < b.Line
+		})
+	}
+	return report, nil
+}
+
+/*
+ * Parse a post's front matter, keeping track of the line each header is on
+ */
+func readCheckedPost(filePath string) (checkedPost, error) {
+	post := checkedPost{
+		path:    filePath,
+		lines:   make(map[string]int),
+		related: make(map[int]int),
+		fences:  make(map[int]string),
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return post, err
+	}
+	defer file.Close()
+
+	/*
+	 * Collect the YAML between the first pair of --- lines, then the
+	 * opening fence of each code block after it
+	 */
+	var yamlBuilder strings.Builder
+	scanner := bufio.NewScanner(file)
+	end, fence := 0, ""
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		switch {
+		case end > 0:
+			trimmed := strings.TrimSpace(line)
+			if fence != "" {
+				if strings.HasPrefix(trimmed, fence) {
+					fence = ""
+				}
+			} else if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				fence = trimmed[:3]
+				if info := strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1])); info != "" {
+					post.fences[n] = info
+				}
+			}
+		case line == "---":
+			if post.start > 0 {
+				end = n
+				continue
+			}
+			post.start = n
+		case post.start > 0:
+			yamlBuilder.WriteString(line + "\n")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return post, err
+	}
+	if post.start == 0 {
+		return post, fmt.Errorf("no front matter found")
+	}
+
+	frontMatter, _, err := parseFileWithHeaders(filePath)
+	if err != nil {
+		return post, err
+	}
+	post.frontMatter = *frontMatter
+	post.status = PostStatus(frontMatter.Status)
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(yamlBuilder.String()), &root); err != nil || len(root.Content) == 0 {
+		return post, nil
+	}
+	mapping := root.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		post.lines[key.Value] = post.start + key.Line
+		if key.Value == "related" && value.Kind == yaml.SequenceNode {
+			for j, item := range value.Content {
+				post.related[j] = post.start + item.Line
+			}
+		}
+	}
+	return post, nil
+}
@@ -0,0 +1,179 @@
+package site
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	withRelated := strings.Replace(post("Linked", "linked", "2025-01-04T10:00:00Z", "public"),
+		"status: public\n", "status: public\nrelated:\n  - first\n  - missing\n", 1)
+
+	tests := []struct {
+		name     string
+		posts    map[string]string
+		pages    []Page
+		strict   bool      // related.strict
+		expected []Problem // File is relative to the input folder, empty for the config
+		warnings []Problem
+	}{
+		{
+			name: "Clean site",
+			posts: map[string]string{
+				"20250101.first.md": post("First", "first", "2025-01-01T10:00:00Z", "public"),
+			},
+		},
+		{
+			name: "Bad status and date",
+			posts: map[string]string{
+				"20250101.first.md": post("First", "first", "yesterday", "secret"),
+			},
+			expected: []Problem{
+				{File: "20250101.first.md", Line: 7, Message: "error parsing date"},
+				{File: "20250101.first.md", Line: 10, Message: "Invalid value for status: secret"},
+			},
+		},
+		{
+			name: "Missing headers and bad link",
+			posts: map[string]string{
+				"20250101.first.md": post("", "../etc", "2025-01-01T10:00:00Z", "public"),
+			},
+			expected: []Problem{
+				{File: "20250101.first.md", Line: 1, Message: "missing a required header: title"},
+				{File: "20250101.first.md", Line: 3, Message: "path traversal"},
+			},
+		},
+		{
+			name: "Duplicate link",
+			posts: map[string]string{
+				"20250101.first.md":  post("First", "first", "2025-01-01T10:00:00Z", "public"),
+				"20250102.second.md": post("Second", "first", "2025-01-02T10:00:00Z", "private"),
+			},
+			expected: []Problem{
+				{File: "20250102.second.md", Line: 3, Message: "duplicate link first"},
+			},
+		},
+		{
+			name: "Generated link",
+			posts: map[string]string{
+				"20250101.first.md": post("First", "tags", "2025-01-01T10:00:00Z", "public"),
+			},
+			expected: []Problem{
+				{File: "20250101.first.md", Line: 3, Message: "duplicate link tags: also used by a generated page"},
+			},
+		},
+		{
+			name: "Duplicate page link",
+			posts: map[string]string{
+				"20250101.first.md": post("First", "first", "2025-01-01T10:00:00Z", "public"),
+			},
+			pages: []Page{
+				{Template: "index.html", Title: "About", Link: "about"},
+				{Template: "index.html", Title: "About Me", Link: "about"},
+			},
+			expected: []Problem{
+				{Message: "page About Me: duplicate link about: also used by another page"},
+			},
+		},
+		{
+			name: "Missing template",
+			posts: map[string]string{
+				"20250101.first.md": strings.Replace(post("First", "first", "2025-01-01T10:00:00Z", "public"), "default.html", "nope.html", 1),
+			},
+			expected: []Problem{
+				{File: "20250101.first.md", Line: 8, Message: "nope.html"},
+			},
+		},
+		{
+			name: "Related links",
+			posts: map[string]string{
+				"20250101.first.md":  post("First", "first", "2025-01-01T10:00:00Z", "public"),
+				"20250104.linked.md": withRelated,
+			},
+			warnings: []Problem{
+				{File: "20250104.linked.md", Line: 13, Message: "related post missing does not exist"},
+			},
+		},
+		{
+			name: "Strict related links",
+			posts: map[string]string{
+				"20250101.first.md":  post("First", "first", "2025-01-01T10:00:00Z", "public"),
+				"20250104.linked.md": withRelated,
+			},
+			strict: true,
+			expected: []Problem{
+				{File: "20250104.linked.md", Line: 13, Message: "related post missing does not exist"},
+			},
+		},
+		{
+			name: "Related scheduled post",
+			posts: map[string]string{
+				"20250101.first.md":  post("First", "first", "2999-01-01T10:00:00Z", "public"),
+				"20250104.linked.md": strings.Replace(withRelated, "  - missing\n", "", 1),
+				"20250105.later.md":  strings.NewReplacer("link: linked", "link: later", "2025-01-04T10:00:00Z", "2999-01-02T10:00:00Z").Replace(withRelated),
+			},
+			strict: true,
+			expected: []Problem{
+				{File: "20250104.linked.md", Line: 12, Message: "related post first is not published"},
+			},
+		},
+		{
+			name: "Broken YAML",
+			posts: map[string]string{
+				"20250101.first.md": "---\ntitle: First\nlink: [first\n---\n",
+			},
+			expected: []Problem{
+				{File: "20250101.first.md", Message: "failed to parse front matter"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSite(t, tt.posts)
+			s.Config.Pages = tt.pages
+			s.Config.Related.Strict = tt.strict
+			report, err := s.Check(context.Background())
+			if err != nil {
+				t.Fatalf("Check() = %v", err)
+			}
+			if report.Posts != len(tt.posts) {
+				t.Errorf("expected %d posts checked, got %d", len(tt.posts), report.Posts)
+			}
+			for kind, problems := range map[string][2][]Problem{"problem": {tt.expected, report.Problems}, "warning": {tt.warnings, report.Warnings}} {
+				expected, actual := problems[0], problems[1]
+				if len(actual) != len(expected) {
+					t.Fatalf("expected %d %ss, got %v", len(expected), kind, actual)
+				}
+				for i, expected := range expected {
+					problem := actual[i]
+					if expected.File != "" {
+						expected.File = filepath.Join(s.Config.InputDir, expected.File)
+					}
+					if problem.File != expected.File ||
+						(expected.Line != 0 && problem.Line != expected.Line) ||
+						!strings.Contains(problem.Message, expected.Message) {
+						t.Errorf("%s %d: expected %v, got %v", kind, i, expected, problem)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckWritesNothing(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md": post("First", "first", "2025-01-01T10:00:00Z", "public"),
+	})
+	if _, err := s.Check(context.Background()); err != nil {
+		t.Fatalf("Check() = %v", err)
+	}
+	for _, dir := range []string{s.Config.OutputDir, s.Config.CacheDir} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("expected %s not to exist", dir)
+		}
+	}
+}
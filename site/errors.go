@@ -0,0 +1,104 @@
+package site
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * Typed errors returned by Load and Build. Use errors.As to inspect them.
+ */
+
+/*
+ * Configuration file could not be read or is incomplete
+ */
+type ConfigError struct {
+	Path string // Config file, empty when the Config was built in code
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("config: %v", e.Err)
+	}
+	return fmt.Sprintf("config %s: %v", e.Path, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+/*
+ * Post could not be read or parsed
+ */
+type PostError struct {
+	Path string
+	Err  error
+}
+
+func (e *PostError) Error() string {
+	return fmt.Sprintf("post %s: %v", e.Path, e.Err)
+}
+
+func (e *PostError) Unwrap() error {
+	return e.Err
+}
+
+/*
+ * Post front matter failed validation; lists every problem found
+ */
+type ValidationError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("Post %s has the following issues:\n%s", e.Path, strings.Join(e.Problems, "\n"))
+}
+
+/*
+ * Two posts, or a post and a page, share the same link
+ */
+type DuplicateLinkError struct {
+	Link string
+	Path string // Post file, empty when the collision is with a page
+}
+
+func (e *DuplicateLinkError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("duplicate link in page %s", e.Link)
+	}
+	return fmt.Sprintf("duplicate link %s in post %s", e.Link, e.Path)
+}
+
+/*
+ * Template could not be parsed or executed
+ */
+type TemplateError struct {
+	Template string
+	Err      error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("template %s: %v", e.Template, e.Err)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+/*
+ * Output file or folder could not be written
+ */
+type WriteError struct {
+	Path string
+	Err  error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("failed to write '%s': %v", e.Path, e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,451 @@
+package site
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+)
+
+/*
+ * Options for the Atom and RSS feeds
+ */
+type FeedsConfig struct {
+	FullContent bool         `yaml:"full_content"` // Include each post's rendered HTML, not just its description
+	PerTag      bool         `yaml:"per_tag"`      // Also write rss.xml and atom.xml for every tag
+	Limit       int          `yaml:"limit"`        // Newest posts per feed, 0 for all
+	Image       string       `yaml:"image"`        // URL of the RSS channel image and Atom logo
+	Icon        string       `yaml:"icon"`         // URL of the Atom icon, e.g. a favicon
+	TTL         int          `yaml:"ttl"`          // Minutes RSS readers may cache the feed
+	ITunes      ITunesConfig `yaml:"itunes"`
+}
+
+/*
+ * Channel details podcast directories read from the RSS feed
+ */
+type ITunesConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	Author      string `yaml:"author"`
+	OwnerName   string `yaml:"owner_name"`
+	OwnerEmail  string `yaml:"owner_email"`
+	Image       string `yaml:"image"` // Square cover art, 1400 to 3000 pixels
+	Category    string `yaml:"category"`
+	Subcategory string `yaml:"subcategory"`
+	Explicit    bool   `yaml:"explicit"`
+	Type        string `yaml:"type"` // episodic or serial
+}
+
+/*
+ * What a feed is about: the whole blog or a single tag
+ */
+type feedSource struct {
+	Title       string
+	Description string
+	Link        string // Page the feed belongs to
+	RSS         string // URL of the RSS feed
+	Atom        string // URL of the Atom feed
+	Dir         string // Output folder
+	Key         string // Tells feeds apart in the build cache, empty for the blog
+}
+
+func (s *Site) siteFeed() feedSource {
+	config := s.Config
+	description := config.Description
+	if description == "" {
+		description = fmt.Sprintf("Latest posts from %s", config.BlogName)
+	}
+	return feedSource{
+		Title:       config.BlogName,
+		Description: description,
+		Link:        buildRootLink(config),
+		RSS:         buildRSSLink(config),
+		Atom:        buildAtomLink(config),
+		Dir:         config.OutputDir,
+	}
+}
+
+func (s *Site) tagFeed(tag Tag) feedSource {
+	config := s.Config
+	return feedSource{
+		Title:       fmt.Sprintf("%s: %s", config.BlogName, tag.TagName),
+		Description: fmt.Sprintf("Latest posts tagged %s from %s", tag.TagName, config.BlogName),
+		Link:        tag.URL,
+		RSS:         tag.RSS,
+		Atom:        tag.Atom,
+		Dir:         filepath.Join(config.OutputDir, "tags", tag.Slug),
+		Key:         "tag:" + tag.Slug,
+	}
+}
+
+type RSSFeed struct {
+	XMLName      xml.Name   `xml:"rss"`
+	Version      string     `xml:"version,attr"`
+	XmlnsAtom    string     `xml:"xmlns:atom,attr"`
+	XmlnsContent string     `xml:"xmlns:content,attr,omitempty"`
+	XmlnsDC      string     `xml:"xmlns:dc,attr"`
+	XmlnsITunes  string     `xml:"xmlns:itunes,attr,omitempty"`
+	Channel      RSSChannel `xml:"channel"`
+}
+
+type RSSChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Self          RSSLink   `xml:"atom:link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	Copyright     string    `xml:"copyright,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Generator     string    `xml:"generator"`
+	TTL           int       `xml:"ttl,omitempty"`
+	Image         *RSSImage `xml:"image,omitempty"`
+	*ITunesChannel
+	Items []RSSItem `xml:"item"`
+}
+
+type ITunesChannel struct {
+	Author   string          `xml:"itunes:author,omitempty"`
+	Owner    *ITunesOwner    `xml:"itunes:owner,omitempty"`
+	Image    *ITunesImage    `xml:"itunes:image,omitempty"`
+	Category *ITunesCategory `xml:"itunes:category,omitempty"`
+	Explicit string          `xml:"itunes:explicit"`
+	Type     string          `xml:"itunes:type,omitempty"`
+}
+
+type ITunesOwner struct {
+	Name  string `xml:"itunes:name,omitempty"`
+	Email string `xml:"itunes:email,omitempty"`
+}
+
+type ITunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type ITunesCategory struct {
+	Text        string          `xml:"text,attr"`
+	Subcategory *ITunesCategory `xml:"itunes:category,omitempty"`
+}
+
+type RSSLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type RSSImage struct {
+	URL   string `xml:"url"`
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+}
+
+type RSSItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Guid        string        `xml:"guid"`
+	Description string        `xml:"description"`
+	Author      string        `xml:"author,omitempty"`     // Email address, as RSS requires
+	Creator     string        `xml:"dc:creator,omitempty"` // Name, when there is no email address
+	Categories  []string      `xml:"category"`
+	PubDate     string        `xml:"pubDate"`
+	Enclosure   *RSSEnclosure `xml:"enclosure,omitempty"`
+	Duration    string        `xml:"itunes:duration,omitempty"`
+	Content     string        `xml:"content:encoded,omitempty"`
+}
+
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+/*
+ * The iTunes channel block, nil unless feeds.itunes.enabled is set
+ */
+func iTunesChannel(config Config) *ITunesChannel {
+	itunes := config.Feeds.ITunes
+	if !itunes.Enabled {
+		return nil
+	}
+	channel := &ITunesChannel{
+		Author:   itunes.Author,
+		Explicit: fmt.Sprint(itunes.Explicit),
+		Type:     itunes.Type,
+	}
+	if channel.Author == "" {
+		channel.Author = config.Author
+	}
+	if itunes.OwnerName != "" || itunes.OwnerEmail != "" {
+		channel.Owner = &ITunesOwner{Name: itunes.OwnerName, Email: itunes.OwnerEmail}
+	}
+	if itunes.Image != "" {
+		channel.Image = &ITunesImage{Href: itunes.Image}
+	}
+	if itunes.Category != "" {
+		channel.Category = &ITunesCategory{Text: itunes.Category}
+		if itunes.Subcategory != "" {
+			channel.Category.Subcategory = &ITunesCategory{Text: itunes.Subcategory}
+		}
+	}
+	return channel
+}
+
+var urlAttribute = regexp.MustCompile(`(\s(?:href|src)=")([^"]*)(")`)
+
+/*
+ * Rewrite relative href and src attributes as absolute URLs so feed readers,
+ * which don't know where a post lives, can follow them
+ */
+func absolutizeURLs(html string, base string) string {
+	return urlAttribute.ReplaceAllStringFunc(html, func(attribute string) string {
+		parts := urlAttribute.FindStringSubmatch(attribute)
+		return parts[1] + resolveURL(parts[2], base) + parts[3]
+	})
+}
+
+/*
+ * Rendered HTML to embed in feeds, empty unless feeds.full_content is set
+ * and the post hasn't opted out
+ */
+func (s *Site) feedContent(post Post) string {
+	if !s.Config.Feeds.FullContent || post.FrontMatter.SummaryOnly {
+		return ""
+	}
+	content, _ := s.publishPost(post)
+	return absolutizeURLs(string(content), post.URL)
+}
+
+/*
+ * Newest posts up to feeds.limit, newest first whatever order they came in
+ */
+func (s *Site) feedPosts(posts []Post) []Post {
+	posts = slices.Clone(posts)
+	slices.SortStableFunc(posts, func(a, b Post) int {
+		return b.PubTime.Compare(a.PubTime)
+	})
+	if limit := s.Config.Feeds.Limit; limit > 0 && limit < len(posts) {
+		return posts[:limit]
+	}
+	return posts
+}
+
+/*
+ * Latest update of any of the posts, zero without posts
+ */
+func lastUpdated(posts []Post) time.Time {
+	var updated time.Time
+	for _, post := range posts {
+		if post.UpdatedTime.After(updated) {
+			updated = post.UpdatedTime
+		}
+	}
+	return updated
+}
+
+/*
+ * RSS 2.0
+ */
+
+func (s *Site) generateRSSFeed(source feedSource, posts []Post, out io.Writer) error {
+	config := s.Config
+	posts = s.feedPosts(posts)
+	items := make([]RSSItem, len(posts))
+	for i, post := range posts {
+		items[i] = RSSItem{
+			Title:       post.FrontMatter.Title,
+			Link:        post.URL,
+			Guid:        post.URL,
+			Description: post.Summary,
+			Categories:  convertTagsToStrings(post.Tags),
+			PubDate:     post.PubTime.Format(time.RFC1123Z), // RFC 1123
+			Content:     s.feedContent(post),
+		}
+		if enclosure := post.FrontMatter.Enclosure; enclosure.URL != "" {
+			items[i].Enclosure = &RSSEnclosure{URL: enclosure.URL, Length: enclosure.Length, Type: enclosure.Type}
+			if config.Feeds.ITunes.Enabled {
+				items[i].Duration = enclosure.Duration
+			}
+		}
+		if post.FrontMatter.Email != "" {
+			items[i].Author = fmt.Sprintf("%s (%s)", post.FrontMatter.Email, post.FrontMatter.Author)
+		} else {
+			items[i].Creator = post.FrontMatter.Author
+		}
+	}
+
+	rss := RSSFeed{
+		Version:   "2.0",
+		XmlnsAtom: "http://www.w3.org/2005/Atom",
+		XmlnsDC:   "http://purl.org/dc/elements/1.1/",
+		Channel: RSSChannel{
+			Title:         source.Title,
+			Link:          source.Link,
+			Self:          RSSLink{Href: source.RSS, Rel: "self", Type: "application/rss+xml"},
+			Description:   source.Description,
+			TTL:           config.Feeds.TTL,
+			ITunesChannel: iTunesChannel(config),
+			Language:      config.Language,
+			Copyright:     config.Rights,
+			Generator:     "Draft/" + s.Version,
+			Items:         items,
+		},
+	}
+	if config.Feeds.ITunes.Enabled {
+		rss.XmlnsITunes = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	}
+	if config.Feeds.FullContent {
+		rss.XmlnsContent = "http://purl.org/rss/1.0/modules/content/"
+	}
+	if len(posts) > 0 {
+		rss.Channel.LastBuildDate = lastUpdated(posts).Format(time.RFC1123Z)
+	}
+	if config.Feeds.Image != "" {
+		// Title and link must match the channel's
+		rss.Channel.Image = &RSSImage{URL: config.Feeds.Image, Title: source.Title, Link: source.Link}
+	}
+
+	outputPath := filepath.Join(source.Dir, "rss.xml")
+	if s.unchanged(outputPath, hashKey(s.siteKey, "rss", source.Key, postsKey(posts))) {
+		return nil
+	}
+	file, err := s.create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	file.WriteString(xml.Header)
+	if err := encoder.Encode(rss); err != nil {
+		return &WriteError{Path: outputPath, Err: fmt.Errorf("failed to encode RSS feed: %w", err)}
+	}
+
+	fmt.Fprintf(out, "📔 RSS: %s\n", outputPath)
+	return nil
+}
+
+/*
+ * Atom
+ */
+
+func (s *Site) generateAtomFeed(source feedSource, posts []Post, out io.Writer) error {
+	type AtomLink struct {
+		Href   string `xml:"href,attr"`
+		Rel    string `xml:"rel,attr,omitempty"`
+		Type   string `xml:"type,attr,omitempty"`
+		Length int64  `xml:"length,attr,omitempty"`
+	}
+
+	type AtomAuthor struct {
+		Name  string `xml:"name"`
+		Email string `xml:"email,omitempty"`
+	}
+
+	type AtomContent struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	}
+
+	type AtomCategory struct {
+		Term string `xml:"term,attr"`
+	}
+
+	type AtomEntry struct {
+		Title      string         `xml:"title"`
+		Link       []AtomLink     `xml:"link"`
+		Id         string         `xml:"id"`
+		Published  string         `xml:"published,omitempty"`
+		Updated    string         `xml:"updated"`
+		Summary    string         `xml:"summary"`
+		Content    *AtomContent   `xml:"content,omitempty"`
+		Author     AtomAuthor     `xml:"author"`
+		Categories []AtomCategory `xml:"category"`
+	}
+
+	type AtomFeed struct {
+		XMLName  xml.Name    `xml:"feed"`
+		Xmlns    string      `xml:"xmlns,attr"`
+		Title    string      `xml:"title"`
+		Subtitle string      `xml:"subtitle"`
+		Link     []AtomLink  `xml:"link"`
+		Id       string      `xml:"id"`
+		Updated  string      `xml:"updated"`
+		Author   AtomAuthor  `xml:"author"`
+		Icon     string      `xml:"icon,omitempty"`
+		Logo     string      `xml:"logo,omitempty"`
+		Entries  []AtomEntry `xml:"entry"`
+	}
+
+	config := s.Config
+	posts = s.feedPosts(posts)
+	entries := make([]AtomEntry, len(posts))
+	for i, post := range posts {
+		// Atom requires a name for every author
+		author := AtomAuthor{Name: post.FrontMatter.Author, Email: post.FrontMatter.Email}
+		if author.Name == "" {
+			author = AtomAuthor{Name: config.Author, Email: config.Email}
+		}
+		entries[i] = AtomEntry{
+			Title: post.FrontMatter.Title,
+			Link: []AtomLink{
+				{Href: post.URL, Rel: "alternate", Type: "text/html"},
+			},
+			Id:        post.URL,
+			Published: post.PubTime.Format(time.RFC3339),
+			Updated:   post.UpdatedTime.Format(time.RFC3339),
+			Summary:   post.Summary,
+			Author:    author,
+		}
+		if enclosure := post.FrontMatter.Enclosure; enclosure.URL != "" {
+			entries[i].Link = append(entries[i].Link, AtomLink{Href: enclosure.URL, Rel: "enclosure", Type: enclosure.Type, Length: enclosure.Length})
+		}
+		for _, tag := range post.Tags {
+			entries[i].Categories = append(entries[i].Categories, AtomCategory{Term: tag.TagName})
+		}
+		if content := s.feedContent(post); content != "" {
+			entries[i].Content = &AtomContent{Type: "html", Value: content}
+		}
+	}
+	updated := lastUpdated(posts)
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+
+	atom := AtomFeed{
+		Xmlns:    "http://www.w3.org/2005/Atom",
+		Title:    source.Title,
+		Subtitle: source.Description,
+		Link: []AtomLink{
+			{Href: source.Atom, Rel: "self", Type: "application/atom+xml"},
+			{Href: source.Link, Rel: "alternate", Type: "text/html"},
+		},
+		Id:      source.Link,
+		Updated: updated.Format(time.RFC3339),
+		Author:  AtomAuthor{Name: config.BlogName, Email: config.Email},
+		Icon:    config.Feeds.Icon,
+		Logo:    config.Feeds.Image,
+		Entries: entries,
+	}
+
+	outputPath := filepath.Join(source.Dir, "atom.xml")
+	if s.unchanged(outputPath, hashKey(s.siteKey, "atom", source.Key, postsKey(posts))) {
+		return nil
+	}
+	file, err := s.create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	file.WriteString(xml.Header)
+	if err := encoder.Encode(atom); err != nil {
+		return &WriteError{Path: outputPath, Err: fmt.Errorf("failed to encode Atom feed: %w", err)}
+	}
+
+	fmt.Fprintf(out, "⚛️  Atom: %s\n", outputPath)
+	return nil
+}
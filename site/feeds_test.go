@@ -0,0 +1,267 @@
+package site
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAbsolutizeURLs(t *testing.T) {
+	base := "https://example.com/blog/hello/"
+	tests := []struct {
+		html     string
+		expected string
+	}{
+		{`<img src="cat.jpg">`, `<img src="https://example.com/blog/hello/cat.jpg">`},
+		{`<a href="../other/">x</a>`, `<a href="https://example.com/blog/other/">x</a>`},
+		{`<a href="/about/">x</a>`, `<a href="https://example.com/about/">x</a>`},
+		{`<a href="https://example.org/">x</a>`, `<a href="https://example.org/">x</a>`},
+		{`<a href="mailto:me@example.com">x</a>`, `<a href="mailto:me@example.com">x</a>`},
+		{`<a href="#top">x</a>`, `<a href="#top">x</a>`},
+		{`<p>src="cat.jpg"</p>`, `<p>src="cat.jpg"</p>`},
+	}
+
+	for _, tt := range tests {
+		if actual := absolutizeURLs(tt.html, base); actual != tt.expected {
+			t.Errorf("absolutizeURLs(%s) = %s, expected %s", tt.html, actual, tt.expected)
+		}
+	}
+}
+
+func TestFullContentFeeds(t *testing.T) {
+	summaryOnly := strings.Replace(post("Second", "second", "2025-01-02T10:00:00Z", "public"), "status: public\n", "status: public\nsummary_only: true\n", 1)
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md":  post("First", "first", "2025-01-01T10:00:00Z", "public") + "\n![cat](cat.jpg)\n",
+		"20250102.second.md": summaryOnly,
+	})
+	s.Config.Feeds.FullContent = true
+
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	for name, tag := range map[string]string{"rss.xml": "<content:encoded>", "atom.xml": `<content type="html">`} {
+		feed, err := os.ReadFile(filepath.Join(s.Config.OutputDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Count(string(feed), tag) != 1 {
+			t.Errorf("%s: expected exactly one post with full content, got %s", name, feed)
+		}
+		if !strings.Contains(string(feed), "https://example.com/first/cat.jpg") {
+			t.Errorf("%s: expected image URL to be absolute, got %s", name, feed)
+		}
+	}
+}
+
+func TestJSONFeed(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md": post("First", "first", "2025-01-01T10:00:00Z", "public"),
+	})
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(s.Config.OutputDir, "feed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed JSONFeed
+	if err := json.Unmarshal(content, &feed); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	expected := JSONFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       "Test Blog",
+		HomePageURL: "https://example.com/",
+		FeedURL:     "https://example.com/feed.json",
+		Items: []JSONFeedItem{{
+			ID:            "https://example.com/first/",
+			URL:           "https://example.com/first/",
+			Title:         "First",
+			Summary:       "Example description",
+			ContentText:   "Example description",
+			DatePublished: "2025-01-01T10:00:00Z",
+			Tags:          []string{"meta"},
+			Authors:       []JSONFeedAuthor{{Name: "harrison"}},
+		}},
+	}
+	if diff := cmp.Diff(expected, feed); diff != "" {
+		t.Errorf("feed.json mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestPerTagFeeds(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md": post("First", "first", "2025-01-01T10:00:00Z", "public"),
+	})
+	s.Config.Feeds.PerTag = true
+
+	result, err := s.Build(context.Background())
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	tag := result.Posts[0].Tags[0]
+	if tag.RSS != "https://example.com/tags/meta/rss.xml" || tag.Atom != "https://example.com/tags/meta/atom.xml" {
+		t.Errorf("unexpected tag feed URLs %s, %s", tag.RSS, tag.Atom)
+	}
+	for name, self := range map[string]string{"rss.xml": "<link>https://example.com/tags/meta/</link>", "atom.xml": `href="https://example.com/tags/meta/atom.xml" rel="self"`} {
+		feed, err := os.ReadFile(filepath.Join(s.Config.OutputDir, "tags", "meta", name))
+		if err != nil {
+			t.Fatalf("expected tag feed %s: %v", name, err)
+		}
+		if !strings.Contains(string(feed), self) || !strings.Contains(string(feed), "https://example.com/first/") {
+			t.Errorf("%s: unexpected feed %s", name, feed)
+		}
+	}
+}
+
+func TestPerTagFeedOrder(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md":  post("First", "first", "2025-01-01T10:00:00Z", "public"),
+		"20250102.second.md": post("Second", "second", "2025-01-02T10:00:00Z", "public"),
+		"20250103.third.md":  post("Third", "third", "2025-01-03T10:00:00Z", "public"),
+	})
+	s.Config.Feeds.PerTag = true
+
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	for _, name := range []string{"rss.xml", "atom.xml"} {
+		feed, err := os.ReadFile(filepath.Join(s.Config.OutputDir, "tags", "meta", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		third := strings.Index(string(feed), "https://example.com/third/")
+		second := strings.Index(string(feed), "https://example.com/second/")
+		first := strings.Index(string(feed), "https://example.com/first/")
+		if third < 0 || !(third < second && second < first) {
+			t.Errorf("%s: expected posts newest first, got %s", name, feed)
+		}
+	}
+}
+
+func TestFeedPosts(t *testing.T) {
+	day := func(d int) Post {
+		return Post{URL: fmt.Sprint(d), PubTime: time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC)}
+	}
+	s := &Site{Config: Config{Feeds: FeedsConfig{Limit: 2}}}
+
+	// Tag listings used to arrive oldest first
+	for _, posts := range [][]Post{{day(3), day(2), day(1)}, {day(1), day(2), day(3)}} {
+		var urls []string
+		for _, post := range s.feedPosts(posts) {
+			urls = append(urls, post.URL)
+		}
+		if strings.Join(urls, ",") != "3,2" {
+			t.Errorf("feedPosts() = %v, expected the two newest posts", urls)
+		}
+	}
+}
+
+func TestFeedMetadata(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md":  post("First", "first", "2025-01-01T10:00:00Z", "public"),
+		"20250102.second.md": strings.Replace(post("Second", "second", "2025-01-02T10:00:00Z", "public"), "status: public\n", "status: public\nupdated: 2025-02-01T10:00:00Z\n", 1),
+		"20250103.third.md":  post("Third", "third", "2025-01-03T10:00:00Z", "public"),
+	})
+	s.Config.Description = "All about testing"
+	s.Config.Feeds = FeedsConfig{Limit: 2, TTL: 60, Image: "https://example.com/logo.png", Icon: "https://example.com/favicon.ico"}
+
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"rss.xml", []string{
+			`<description>All about testing</description>`,
+			`<atom:link href="https://example.com/rss.xml" rel="self" type="application/rss+xml"></atom:link>`,
+			`<lastBuildDate>Sat, 01 Feb 2025 10:00:00 +0000</lastBuildDate>`,
+			`<ttl>60</ttl>`,
+			`<url>https://example.com/logo.png</url>`,
+			`<category>meta</category>`,
+			`<dc:creator>harrison</dc:creator>`,
+		}},
+		{"atom.xml", []string{
+			`<subtitle>All about testing</subtitle>`,
+			`<updated>2025-02-01T10:00:00Z</updated>`,
+			`<icon>https://example.com/favicon.ico</icon>`,
+			`<logo>https://example.com/logo.png</logo>`,
+			`<category term="meta"></category>`,
+		}},
+	}
+
+	for _, tt := range tests {
+		feed, err := os.ReadFile(filepath.Join(s.Config.OutputDir, tt.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range tt.expected {
+			if !strings.Contains(string(feed), expected) {
+				t.Errorf("%s: expected %s", tt.name, expected)
+			}
+		}
+		if strings.Contains(string(feed), "https://example.com/first/") {
+			t.Errorf("%s: expected the oldest post to be left out by feeds.limit", tt.name)
+		}
+	}
+}
+
+func TestEnclosures(t *testing.T) {
+	episode := strings.Replace(post("Episode", "episode", "2025-01-02T10:00:00Z", "public"), "status: public\n",
+		"status: public\nenclosure:\n  url: https://example.com/ep1.mp3\n  type: audio/mpeg\n  length: 1024\n  duration: \"12:34\"\n", 1)
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md":   post("First", "first", "2025-01-01T10:00:00Z", "public"),
+		"20250102.episode.md": episode,
+	})
+	s.Config.Feeds.ITunes = ITunesConfig{Enabled: true, Category: "Technology", Image: "https://example.com/cover.jpg"}
+
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"rss.xml", []string{
+			`xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"`,
+			`<itunes:image href="https://example.com/cover.jpg"></itunes:image>`,
+			`<itunes:category text="Technology"></itunes:category>`,
+			`<itunes:explicit>false</itunes:explicit>`,
+			`<enclosure url="https://example.com/ep1.mp3" length="1024" type="audio/mpeg"></enclosure>`,
+			`<itunes:duration>12:34</itunes:duration>`,
+		}},
+		{"atom.xml", []string{
+			`<link href="https://example.com/ep1.mp3" rel="enclosure" type="audio/mpeg" length="1024"></link>`,
+		}},
+	}
+
+	for _, tt := range tests {
+		feed, err := os.ReadFile(filepath.Join(s.Config.OutputDir, tt.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range tt.expected {
+			if !strings.Contains(string(feed), expected) {
+				t.Errorf("%s: expected %s", tt.name, expected)
+			}
+		}
+		if strings.Count(string(feed), "ep1.mp3") != 1 {
+			t.Errorf("%s: expected one enclosure", tt.name)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package site
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/gomarkdown/markdown/ast"
+)
+
+/*
+ * Syntax highlighting of fenced code blocks with chroma. The fence info
+ * string may carry options after the language, Hugo style:
+ *
+ *   ```go {linenos=true, hl_lines="2 4-6", linenostart=10}
+ */
+
+type HighlightConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	Style       string `yaml:"style"`        // Chroma style, defaults to github
+	Classes     bool   `yaml:"classes"`      // CSS classes instead of inline styles, see `draft highlight-css`
+	LineNumbers bool   `yaml:"line_numbers"` // Number every block unless its fence says otherwise
+}
+
+const defaultHighlightStyle = "github"
+
+func highlightStyle(name string) (*chroma.Style, error) {
+	if name == "" {
+		name = defaultHighlightStyle
+	}
+	style, ok := styles.Registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown highlight style %s, expected one of: %s", name, strings.Join(styles.Names(), ", "))
+	}
+	return style, nil
+}
+
+/*
+ * Write the stylesheet for highlight.classes
+ */
+func HighlightCSS(w io.Writer, styleName string) error {
+	style, err := highlightStyle(styleName)
+	if err != nil {
+		return err
+	}
+	return chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(w, style)
+}
+
+/*
+ * Options of one code block, from its fence info string
+ */
+type codeOptions struct {
+	language    string
+	lineNumbers bool
+	start       int
+	highlight   [][2]int
+}
+
+func parseFenceInfo(info string, lineNumbers bool) (codeOptions, error) {
+	options := codeOptions{lineNumbers: lineNumbers, start: 1}
+	info = strings.TrimSpace(info)
+	language, attributes, _ := strings.Cut(info, "{")
+	options.language = strings.TrimSpace(language)
+	attributes = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(attributes), "}"))
+
+	// key=value pairs separated by commas or spaces, values may be quoted
+	for attributes != "" {
+		key, rest, ok := strings.Cut(attributes, "=")
+		if !ok {
+			return options, fmt.Errorf("expected key=value in %q", attributes)
+		}
+		key = strings.TrimSpace(key)
+		rest = strings.TrimSpace(rest)
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				return options, fmt.Errorf("unterminated quote in %q", attributes)
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.IndexAny(rest, ", ")
+			if end < 0 {
+				end = len(rest)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+		attributes = strings.TrimLeft(rest, ", ")
+
+		switch key {
+		case "linenos":
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return options, fmt.Errorf("linenos: %w", err)
+			}
+			options.lineNumbers = enabled
+		case "linenostart":
+			start, err := strconv.Atoi(value)
+			if err != nil {
+				return options, fmt.Errorf("linenostart: %w", err)
+			}
+			options.start = start
+		case "hl_lines":
+			ranges, err := parseLineRanges(value)
+			if err != nil {
+				return options, fmt.Errorf("hl_lines: %w", err)
+			}
+			options.highlight = ranges
+		default:
+			return options, fmt.Errorf("unknown option %s", key)
+		}
+	}
+	return options, nil
+}
+
+/*
+ * "2 4-6" or "2,4-6" => [[2 2] [4 6]]
+ */
+func parseLineRanges(value string) ([][2]int, error) {
+	var ranges [][2]int
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' }) {
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil {
+				return nil, err
+			}
+		}
+		if start < 1 || end < start {
+			return nil, fmt.Errorf("invalid range %s", part)
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges, nil
+}
+
+/*
+ * Render hook for publish: fenced code blocks with a known language are
+ * highlighted, anything else is left to the stock renderer. Bad fence
+ * options are ignored here, `draft check` reports them.
+ */
+func highlightHook(config HighlightConfig) func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	style, err := highlightStyle(config.Style)
+	if err != nil {
+		style = styles.Fallback
+	}
+	return func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+		block, ok := node.(*ast.CodeBlock)
+		if !ok || !entering || len(block.Info) == 0 {
+			return ast.GoToNext, false
+		}
+		options, _ := parseFenceInfo(string(block.Info), config.LineNumbers)
+		lexer := lexers.Get(options.language)
+		if lexer == nil {
+			return ast.GoToNext, false
+		}
+		iterator, err := chroma.Coalesce(lexer).Tokenise(nil, string(block.Literal))
+		if err != nil {
+			return ast.GoToNext, false
+		}
+
+		// hl_lines counts from the top of the block, chroma from linenostart
+		var highlight [][2]int
+		for _, r := range options.highlight {
+			highlight = append(highlight, [2]int{r[0] + options.start - 1, r[1] + options.start - 1})
+		}
+		formatter := chromahtml.New(
+			chromahtml.WithClasses(config.Classes),
+			chromahtml.WithLineNumbers(options.lineNumbers),
+			chromahtml.BaseLineNumber(options.start),
+			chromahtml.HighlightLines(highlight),
+		)
+		if err := formatter.Format(w, style, iterator); err != nil {
+			return ast.GoToNext, false
+		}
+		return ast.GoToNext, true
+	}
+}
@@ -0,0 +1,112 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFenceInfo(t *testing.T) {
+	tests := []struct {
+		info        string
+		expected    codeOptions
+		expectError bool
+	}{
+		{info: "go", expected: codeOptions{language: "go", start: 1}},
+		{info: "go {linenos=true}", expected: codeOptions{language: "go", lineNumbers: true, start: 1}},
+		{info: `python {linenos=true, hl_lines="2 4-6", linenostart=10}`, expected: codeOptions{language: "python", lineNumbers: true, start: 10, highlight: [][2]int{{2, 2}, {4, 6}}}},
+		{info: "js {hl_lines=1,3}", expected: codeOptions{language: "js", start: 1, highlight: [][2]int{{1, 1}}}, expectError: true},
+		{info: `js {hl_lines="1,3"}`, expected: codeOptions{language: "js", start: 1, highlight: [][2]int{{1, 1}, {3, 3}}}},
+		{info: "go {linenos=maybe}", expected: codeOptions{language: "go", start: 1}, expectError: true},
+		{info: `go {hl_lines="5-2"}`, expected: codeOptions{language: "go", start: 1}, expectError: true},
+		{info: "go {colour=red}", expected: codeOptions{language: "go", start: 1}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.info, func(t *testing.T) {
+			options, err := parseFenceInfo(tt.info, false)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseFenceInfo() error = %v, expectError %v", err, tt.expectError)
+			}
+			if diff := cmp.Diff(tt.expected, options, cmp.AllowUnexported(codeOptions{})); diff != "" {
+				t.Errorf("parseFenceInfo() mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	md := "```go {linenos=true, hl_lines=\"2\"}\npackage main\nfunc main() {}\n```\n\n```nosuchlanguage\nplain <text>\n```\n"
+
+	tests := []struct {
+		name     string
+		config   HighlightConfig
+		contains []string
+	}{
+		{
+			name:     "Classes",
+			config:   HighlightConfig{Enabled: true, Classes: true},
+			contains: []string{`<pre class="chroma">`, `<span class="kn">package</span>`, `<span class="line hl"><span class="ln">2</span>`, `<code class="language-nosuchlanguage">plain &lt;text&gt;`},
+		},
+		{
+			name:     "Inline",
+			config:   HighlightConfig{Enabled: true, Style: "monokai"},
+			contains: []string{`<pre style="color:#f8f8f2;background-color:#272822;`, `<span style="color:#f92672">package</span>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := string(publish([]byte(md), highlightHook(tt.config)))
+			for _, expected := range tt.contains {
+				if !strings.Contains(html, expected) {
+					t.Errorf("expected %s in %s", expected, html)
+				}
+			}
+		})
+	}
+}
+
+func TestHighlightCSS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := HighlightCSS(&buf, ""); err != nil {
+		t.Fatalf("HighlightCSS() = %v", err)
+	}
+	if !strings.Contains(buf.String(), ".chroma .kn") {
+		t.Errorf("expected class rules, got %s", buf.String())
+	}
+	if err := HighlightCSS(&buf, "nosuchstyle"); err == nil {
+		t.Errorf("expected an error for an unknown style")
+	}
+}
+
+func TestCheckFenceOptions(t *testing.T) {
+	content := post("First", "first", "2025-01-01T10:00:00Z", "public") + "\n```go {linenos=maybe}\nfunc main() {}\n```\n"
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md": content,
+	})
+	s.Config.Highlight = HighlightConfig{Enabled: true}
+
+	report, err := s.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check() = %v", err)
+	}
+	path := filepath.Join(s.Config.InputDir, "20250101.first.md")
+	if len(report.Problems) != 1 || report.Problems[0].File != path || report.Problems[0].Line != 17 || !strings.HasPrefix(report.Problems[0].Message, "code block: linenos") {
+		t.Errorf("expected a problem on line 17, got %v", report.Problems)
+	}
+
+	// The build is lenient and highlights the block anyway
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	page, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "first", "index.html"))
+	if !strings.Contains(string(page), `<span style="color:#cf222e">func</span>`) {
+		t.Errorf("expected highlighted code in %s", page)
+	}
+}
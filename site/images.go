@@ -0,0 +1,330 @@
+package site
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+	xdraw "golang.org/x/image/draw"
+)
+
+/*
+ * Responsive images: local JPEG and PNG files, in a page bundle or in
+ * static_dir, are re-encoded at each configured width. Re-encoding drops
+ * EXIF and other metadata. Variants are only encoded again when the source
+ * or the settings change.
+ */
+
+type ImagesConfig struct {
+	Widths  []int  `yaml:"widths"`  // Widths of the variants in pixels, none disables the pipeline
+	Sizes   string `yaml:"sizes"`   // `sizes` attribute, defaults to 100vw
+	Quality int    `yaml:"quality"` // JPEG quality from 1 to 100, defaults to 80
+}
+
+const (
+	defaultImageSizes   = "100vw"
+	defaultImageQuality = 80
+)
+
+/*
+ * A processed image. Src is the largest variant, which is the source at
+ * its own width but without metadata.
+ */
+type ResponsiveImage struct {
+	Src    string // Absolute URL
+	Srcset string // e.g. https://example.com/hello/cover-480w.jpg 480w, ...
+	Sizes  string
+	Width  int // Of the source
+	Height int
+}
+
+func imagesEnabled(config Config) bool {
+	return len(config.Images.Widths) > 0
+}
+
+func validateImagesConfig(config ImagesConfig) error {
+	for _, width := range config.Widths {
+		if width <= 0 {
+			return fmt.Errorf("images.widths must be positive, not %d", width)
+		}
+	}
+	if config.Quality < 0 || config.Quality > 100 {
+		return fmt.Errorf("images.quality must be between 1 and 100, not %d", config.Quality)
+	}
+	return nil
+}
+
+/*
+ * Process the front matter image and every image in a post's Markdown.
+ * Results are kept on the post for templates and the render hook.
+ */
+func (s *Site) processImages(post *Post) error {
+	if !imagesEnabled(s.Config) {
+		return nil
+	}
+
+	refs := []string{}
+	if post.FrontMatter.Image != "" {
+		refs = append(refs, post.FrontMatter.Image)
+	}
+	doc := parser.NewWithExtensions(parser.CommonExtensions).Parse([]byte(post.HTML))
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if image, ok := node.(*ast.Image); ok && entering {
+			refs = append(refs, string(image.Destination))
+		}
+		return ast.GoToNext
+	})
+
+	var keys []string
+	for _, ref := range refs {
+		if _, ok := post.images[ref]; ok {
+			continue
+		}
+		source, outputDir, baseURL := s.localImage(*post, ref)
+		if source == "" {
+			continue
+		}
+		responsive, err := s.responsiveImage(source, outputDir, baseURL)
+		if err != nil {
+			return &PostError{Path: post.path, Err: err}
+		}
+		if post.images == nil {
+			post.images = make(map[string]ResponsiveImage)
+		}
+		post.images[ref] = responsive
+		keys = append(keys, ref, responsive.Srcset, fmt.Sprint(responsive.Width, responsive.Height))
+	}
+	// Unfurls and feeds get the variant without EXIF, not the original
+	if responsive, ok := post.images[post.FrontMatter.Image]; ok {
+		post.ImageSet = responsive
+		post.Image = responsive.Src
+	}
+
+	// Rendered HTML depends on the images and the settings, see publishPost
+	if len(keys) > 0 {
+		post.hash = hashKey(append([]string{post.hash, fmt.Sprintf("%#v", s.Config.Images)}, keys...)...)
+	}
+	return nil
+}
+
+/*
+ * Find the file behind an image reference: relative to a page bundle, or
+ * rooted at static_dir
+ *
+ * returns: source path, output folder and URL of the variants, empty if
+ * the image isn't a local JPEG or PNG
+ */
+func (s *Site) localImage(post Post, ref string) (string, string, string) {
+	config := s.Config
+	switch strings.ToLower(path.Ext(ref)) {
+	case ".jpg", ".jpeg", ".png":
+	default:
+		return "", "", ""
+	}
+	if strings.Contains(ref, "://") || strings.HasPrefix(ref, "//") || strings.ContainsAny(ref, "?#") {
+		return "", "", ""
+	}
+
+	var source, outputDir, baseURL string
+	if strings.HasPrefix(ref, "/") {
+		if config.StaticDir == "" {
+			return "", "", ""
+		}
+		name := strings.TrimPrefix(ref, "/")
+		if config.BasePath != "" {
+			name = strings.TrimPrefix(name, config.BasePath+"/")
+		}
+		name = path.Clean(name)
+		source = filepath.Join(config.StaticDir, filepath.FromSlash(name))
+		outputDir = filepath.Join(config.OutputDir, filepath.FromSlash(path.Dir(name)))
+		baseURL = buildStaticLink(config, path.Dir(name)+"/")
+	} else {
+		if post.bundle == "" {
+			return "", "", ""
+		}
+		name := path.Clean(ref)
+		if name == ".." || strings.HasPrefix(name, "../") {
+			return "", "", ""
+		}
+		source = filepath.Join(post.bundle, filepath.FromSlash(name))
+		outputDir = filepath.Join(config.OutputDir, post.FrontMatter.Link, filepath.FromSlash(path.Dir(name)))
+		baseURL = resolveURL(path.Dir(name)+"/", post.URL)
+	}
+	if _, err := os.Stat(source); err != nil {
+		return "", "", ""
+	}
+	return source, outputDir, baseURL
+}
+
+/*
+ * Encode the variants of one source, once per build however many posts
+ * use it
+ */
+func (s *Site) responsiveImage(source, outputDir, baseURL string) (ResponsiveImage, error) {
+	if responsive, ok := s.images[source]; ok {
+		return responsive, nil
+	}
+	config := s.Config
+
+	file, err := os.Open(source)
+	if err != nil {
+		return ResponsiveImage{}, fmt.Errorf("failed to open image '%s': %w", source, err)
+	}
+	defer file.Close()
+	imageConfig, format, err := image.DecodeConfig(file)
+	if err != nil {
+		return ResponsiveImage{}, fmt.Errorf("failed to read image '%s': %w", source, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return ResponsiveImage{}, err
+	}
+
+	quality := config.Images.Quality
+	if quality == 0 {
+		quality = defaultImageQuality
+	}
+	sizes := config.Images.Sizes
+	if sizes == "" {
+		sizes = defaultImageSizes
+	}
+
+	/*
+	 * Never upscale: widths above the source are replaced by the source's
+	 * own width
+	 */
+	var widths []int
+	for _, width := range config.Images.Widths {
+		width = min(width, imageConfig.Width)
+		if !slices.Contains(widths, width) {
+			widths = append(widths, width)
+		}
+	}
+	if !slices.Contains(widths, imageConfig.Width) {
+		widths = append(widths, imageConfig.Width)
+	}
+	slices.Sort(widths)
+
+	ext := filepath.Ext(source)
+	stem := strings.TrimSuffix(filepath.Base(source), ext)
+	sourceKey := hashKey(source, fmt.Sprint(info.Size()), info.ModTime().String())
+	var decoded image.Image
+	var srcset []string
+	for _, width := range widths {
+		name := fmt.Sprintf("%s-%dw%s", stem, width, ext)
+		outputPath := filepath.Join(outputDir, name)
+		srcset = append(srcset, fmt.Sprintf("%s%s %dw", baseURL, name, width))
+		if s.unchanged(outputPath, hashKey(sourceKey, fmt.Sprint(width, quality))) {
+			continue
+		}
+		if decoded == nil {
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return ResponsiveImage{}, err
+			}
+			if decoded, _, err = image.Decode(file); err != nil {
+				return ResponsiveImage{}, fmt.Errorf("failed to decode image '%s': %w", source, err)
+			}
+		}
+		if err := s.writeVariant(decoded, format, width, quality, outputPath); err != nil {
+			return ResponsiveImage{}, err
+		}
+		s.logf("🖼️  Image: %s\n", name)
+	}
+
+	/*
+	 * The original is published as a copy of the largest variant, so a link
+	 * straight to it doesn't give away the metadata. copyBundle and
+	 * writeStatic leave the source alone.
+	 */
+	largest := fmt.Sprintf("%s-%dw%s", stem, imageConfig.Width, ext)
+	originalPath := filepath.Join(outputDir, filepath.Base(source))
+	if !s.unchanged(originalPath, hashKey(sourceKey, "original", fmt.Sprint(imageConfig.Width, quality))) {
+		if err := s.copyFile(filepath.Join(outputDir, largest), originalPath); err != nil {
+			return ResponsiveImage{}, err
+		}
+	}
+
+	responsive := ResponsiveImage{
+		Src:    baseURL + largest,
+		Srcset: strings.Join(srcset, ", "),
+		Sizes:  sizes,
+		Width:  imageConfig.Width,
+		Height: imageConfig.Height,
+	}
+	s.images[source] = responsive
+	return responsive, nil
+}
+
+func (s *Site) writeVariant(source image.Image, format string, width, quality int, outputPath string) error {
+	bounds := source.Bounds()
+	height := max(1, (bounds.Dy()*width+bounds.Dx()/2)/bounds.Dx())
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(resized, resized.Bounds(), source, bounds, xdraw.Src, nil)
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return &WriteError{Path: filepath.Dir(outputPath), Err: err}
+	}
+	file, err := s.create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if format == "png" {
+		err = png.Encode(file, resized)
+	} else {
+		err = jpeg.Encode(file, resized, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return &WriteError{Path: outputPath, Err: fmt.Errorf("failed to encode image: %w", err)}
+	}
+	return nil
+}
+
+/*
+ * Render hook for publish: images with variants get srcset, sizes,
+ * width, height and lazy loading
+ */
+func imageHook(images map[string]ResponsiveImage) func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	return func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+		image, ok := node.(*ast.Image)
+		if !ok {
+			return ast.GoToNext, false
+		}
+		responsive, ok := images[string(image.Destination)]
+		if !ok {
+			return ast.GoToNext, false
+		}
+		if !entering {
+			return ast.GoToNext, true
+		}
+
+		// The alt text is the image's children flattened to text
+		var alt strings.Builder
+		ast.WalkFunc(image, func(node ast.Node, entering bool) ast.WalkStatus {
+			if leaf := node.AsLeaf(); leaf != nil && entering {
+				alt.Write(leaf.Literal)
+			}
+			return ast.GoToNext
+		})
+
+		fmt.Fprintf(w, `<img src="%s" srcset="%s" sizes="%s" width="%d" height="%d" loading="lazy" alt="%s"`,
+			html.EscapeString(responsive.Src), html.EscapeString(responsive.Srcset), html.EscapeString(responsive.Sizes),
+			responsive.Width, responsive.Height, html.EscapeString(alt.String()))
+		if image.Title != nil {
+			fmt.Fprintf(w, ` title="%s"`, html.EscapeString(string(image.Title)))
+		}
+		io.WriteString(w, ` />`)
+		return ast.SkipChildren, true
+	}
+}
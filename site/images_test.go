@@ -0,0 +1,134 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+/*
+ * A 1000x500 JPEG with an EXIF segment right after the start of image
+ */
+func testJPEG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 1000, 500))
+	for x := 0; x < 1000; x++ {
+		for y := 0; y < 500; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	exif := []byte("\xff\xe1\x00\x10Exif\x00\x00GPS-SECRET")
+	return append(append([]byte{0xff, 0xd8}, exif...), buf.Bytes()[2:]...)
+}
+
+func TestResponsiveImages(t *testing.T) {
+	s := newTestSite(t, map[string]string{})
+	s.Config.Images = ImagesConfig{Widths: []int{400, 2000}, Sizes: "(max-width: 600px) 100vw, 600px"}
+
+	bundle := filepath.Join(s.Config.InputDir, "20250101.photos")
+	if err := os.MkdirAll(bundle, 0755); err != nil {
+		t.Fatal(err)
+	}
+	withImage := strings.Replace(post("Photos", "photos", "2025-01-01T10:00:00Z", "public"), "status: public\n", "status: public\nimage: beach.jpg\n", 1)
+	content := withImage + "\n![The beach](beach.jpg \"Sunset\")\n\n![Remote](https://cdn.example.com/x.jpg)\n\n![Map](/img/map.jpg)\n"
+	if err := os.WriteFile(filepath.Join(bundle, "index.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	original := testJPEG(t)
+	if err := os.WriteFile(filepath.Join(bundle, "beach.jpg"), original, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s.Config.StaticDir = filepath.Join(filepath.Dir(s.Config.InputDir), "static")
+	if err := os.MkdirAll(filepath.Join(s.Config.StaticDir, "img"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(s.Config.StaticDir, "img", "map.jpg"), original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.Build(context.Background())
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	// Widths above the source are capped at the source's own width
+	for _, name := range []string{"beach-400w.jpg", "beach-1000w.jpg"} {
+		variant, err := os.ReadFile(filepath.Join(s.Config.OutputDir, "photos", name))
+		if err != nil {
+			t.Fatalf("expected variant %s: %v", name, err)
+		}
+		if bytes.Contains(variant, []byte("GPS-SECRET")) {
+			t.Errorf("%s: expected EXIF to be stripped", name)
+		}
+	}
+	// Links straight to the originals get the largest variant instead
+	for _, name := range []string{"photos/beach.jpg", "img/map.jpg"} {
+		published, err := os.ReadFile(filepath.Join(s.Config.OutputDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatalf("expected %s to be published: %v", name, err)
+		}
+		if bytes.Equal(published, original) || bytes.Contains(published, []byte("GPS-SECRET")) {
+			t.Errorf("%s: expected the original to be replaced by a copy without EXIF", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(s.Config.OutputDir, "photos", "beach-2000w.jpg")); !os.IsNotExist(err) {
+		t.Errorf("images should never be upscaled")
+	}
+	variant, _ := os.Open(filepath.Join(s.Config.OutputDir, "photos", "beach-400w.jpg"))
+	defer variant.Close()
+	if config, _, err := image.DecodeConfig(variant); err != nil || config.Width != 400 || config.Height != 200 {
+		t.Errorf("expected a 400x200 variant, got %+v, %v", config, err)
+	}
+
+	imageSet := result.Posts[0].ImageSet
+	srcset := "https://example.com/photos/beach-400w.jpg 400w, https://example.com/photos/beach-1000w.jpg 1000w"
+	if imageSet.Srcset != srcset || imageSet.Width != 1000 || imageSet.Height != 500 {
+		t.Errorf("unexpected ImageSet %+v", imageSet)
+	}
+	if image := result.Posts[0].Image; image != imageSet.Src {
+		t.Errorf("expected Image to be the variant without EXIF, got %s", image)
+	}
+	feed, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "feed.json"))
+	if !strings.Contains(string(feed), `"image": "https://example.com/photos/beach-1000w.jpg"`) {
+		t.Errorf("expected the variant in the JSON feed: %s", feed)
+	}
+
+	page, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "photos", "index.html"))
+	expected := `<img src="https://example.com/photos/beach-1000w.jpg" srcset="` + srcset + `" sizes="(max-width: 600px) 100vw, 600px" width="1000" height="500" loading="lazy" alt="The beach" title="Sunset" />`
+	if !strings.Contains(string(page), expected) {
+		t.Errorf("expected %s in %s", expected, page)
+	}
+	if !strings.Contains(string(page), `<img src="https://cdn.example.com/x.jpg" alt="Remote" />`) {
+		t.Errorf("remote images should be left alone: %s", page)
+	}
+
+	// Nothing is encoded again when nothing changed
+	result, err = s.Build(context.Background())
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if !slices.Contains(result.Unchanged, filepath.Join(s.Config.OutputDir, "photos", "beach-400w.jpg")) {
+		t.Errorf("expected cached variant to be reused, wrote %v", result.Files)
+	}
+
+	// New settings reach the cached HTML too
+	s.Config.Images.Sizes = "50vw"
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	page, _ = os.ReadFile(filepath.Join(s.Config.OutputDir, "photos", "index.html"))
+	if !strings.Contains(string(page), `sizes="50vw" width="1000"`) {
+		t.Errorf("expected the new sizes in %s", page)
+	}
+}
@@ -0,0 +1,106 @@
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+/*
+ * JSON Feed 1.1: https://www.jsonfeed.org/version/1.1/
+ */
+
+type JSONFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	FeedURL     string           `json:"feed_url"`
+	Description string           `json:"description,omitempty"`
+	Language    string           `json:"language,omitempty"`
+	Authors     []JSONFeedAuthor `json:"authors,omitempty"`
+	Items       []JSONFeedItem   `json:"items"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type JSONFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	Summary       string           `json:"summary,omitempty"`
+	ContentHTML   string           `json:"content_html,omitempty"`
+	ContentText   string           `json:"content_text,omitempty"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Authors       []JSONFeedAuthor `json:"authors,omitempty"`
+}
+
+func jsonFeedAuthors(name string) []JSONFeedAuthor {
+	if name == "" {
+		return nil
+	}
+	return []JSONFeedAuthor{{Name: name}}
+}
+
+func (s *Site) generateJSONFeed(posts []Post) error {
+	config := s.Config
+	posts = s.feedPosts(posts)
+	items := make([]JSONFeedItem, len(posts))
+	for i, post := range posts {
+		items[i] = JSONFeedItem{
+			ID:            post.URL,
+			URL:           post.URL,
+			Title:         post.FrontMatter.Title,
+			Summary:       post.Summary,
+			Image:         post.Image,
+			DatePublished: post.PubTime.Format(time.RFC3339),
+			Tags:          convertTagsToStrings(post.Tags),
+			Authors:       jsonFeedAuthors(post.FrontMatter.Author),
+		}
+		if post.UpdatedTime.After(post.PubTime) {
+			items[i].DateModified = post.UpdatedTime.Format(time.RFC3339)
+		}
+		// Every item needs content. The rendered post only goes out with
+		// feeds.full_content, as in RSS and Atom; otherwise the summary.
+		if content := s.feedContent(post); content != "" {
+			items[i].ContentHTML = content
+		} else {
+			items[i].ContentText = post.Summary
+		}
+	}
+
+	feed := JSONFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       config.BlogName,
+		HomePageURL: buildRootLink(config),
+		FeedURL:     buildJSONFeedLink(config),
+		Description: config.Description,
+		Language:    config.Lang,
+		Authors:     jsonFeedAuthors(config.Author),
+		Items:       items,
+	}
+
+	outputPath := filepath.Join(config.OutputDir, "feed.json")
+	if s.unchanged(outputPath, hashKey(s.siteKey, "jsonfeed", postsKey(posts))) {
+		return nil
+	}
+	file, err := s.create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(feed); err != nil {
+		return &WriteError{Path: outputPath, Err: fmt.Errorf("failed to encode JSON feed: %w", err)}
+	}
+
+	s.logf("🧾 JSON Feed: %s\n", outputPath)
+	return nil
+}
@@ -0,0 +1,123 @@
+package site
+
+import (
+	"fmt"
+	"net/url"
+)
+
+/*
+ * For functions that build paths or URLs, we check if an optional BasePath is
+ * set. This allows us to serve documents from example.com/blog rather than
+ * the root of example.com.
+ */
+
+/*
+ * Folders the build generates, which no post or page may take as its link
+ */
+func reservedLinks(config Config) map[string]bool {
+	reserved := map[string]bool{"tags": true}
+	if archiveEnabled(config) {
+		reserved["archive"] = true
+	}
+	if config.Paginate.Size > 0 {
+		reserved[paginatePath(config)] = true
+	}
+	if config.Search.Enabled && config.Search.Dir != "" {
+		reserved[config.Search.Dir] = true
+	}
+	return reserved
+}
+
+func buildPostLink(config Config, link string) string {
+	if config.BasePath != "" {
+		return fmt.Sprintf("%s/%s/%s/", config.URL, config.BasePath, link)
+	}
+	return fmt.Sprintf("%s/%s/", config.URL, link)
+}
+
+func buildRootLink(config Config) string {
+	if config.BasePath != "" {
+		return fmt.Sprintf("%s/%s/", config.URL, config.BasePath)
+	}
+	return fmt.Sprintf("%s/", config.URL)
+}
+
+func buildTagLink(config Config, slug string) string {
+	if config.BasePath != "" {
+		return fmt.Sprintf("%s/%s/tags/%s/", config.URL, config.BasePath, url.PathEscape(slug))
+	}
+	return fmt.Sprintf("%s/tags/%s/", config.URL, url.PathEscape(slug))
+}
+
+/*
+ * year = 0 for /archive/, month = 0 for a whole year
+ */
+func buildArchiveLink(config Config, year, month int) string {
+	path := "archive/"
+	if year != 0 {
+		path += fmt.Sprintf("%d/", year)
+	}
+	if month != 0 {
+		path += fmt.Sprintf("%02d/", month)
+	}
+	if config.BasePath != "" {
+		return fmt.Sprintf("%s/%s/%s", config.URL, config.BasePath, path)
+	}
+	return fmt.Sprintf("%s/%s", config.URL, path)
+}
+
+func buildTagsLink(config Config) string {
+	if config.BasePath != "" {
+		return fmt.Sprintf("%s/%s/tags/", config.URL, config.BasePath)
+	}
+	return fmt.Sprintf("%s/tags/", config.URL)
+}
+
+func buildRSSLink(config Config) string {
+	if config.BasePath != "" {
+		return fmt.Sprintf("%s/%s/rss.xml", config.URL, config.BasePath)
+	}
+	return fmt.Sprintf("%s/rss.xml", config.URL)
+}
+
+func buildAtomLink(config Config) string {
+	if config.BasePath != "" {
+		return fmt.Sprintf("%s/%s/atom.xml", config.URL, config.BasePath)
+	}
+	return fmt.Sprintf("%s/atom.xml", config.URL)
+}
+
+func buildJSONFeedLink(config Config) string {
+	if config.BasePath != "" {
+		return fmt.Sprintf("%s/%s/feed.json", config.URL, config.BasePath)
+	}
+	return fmt.Sprintf("%s/feed.json", config.URL)
+}
+
+func buildCustomPageLink(config Config, page Page) string {
+	if config.BasePath != "" {
+		return fmt.Sprintf("%s/%s/%s/", config.URL, config.BasePath, page.Link)
+	}
+	return fmt.Sprintf("%s/%s", config.URL, page.Link)
+}
+
+func buildSitemapLink(config Config) string {
+	if config.BasePath != "" {
+		return fmt.Sprintf("%s/%s/%s", config.URL, config.BasePath, "sitemap.xml")
+	}
+	return fmt.Sprintf("%s/%s", config.URL, "sitemap.xml")
+}
+
+func buildRightsLink(config Config) string {
+	if config.BasePath != "" {
+		return fmt.Sprintf("%s/%s/%s", config.URL, config.BasePath, "sitemap.xml")
+	}
+	return fmt.Sprintf("%s/%s", config.URL, "rights/")
+}
+
+func buildStaticLink(config Config, name string) string {
+	if config.BasePath != "" {
+		return fmt.Sprintf("%s/%s/%s", config.URL, config.BasePath, name)
+	}
+	return fmt.Sprintf("%s/%s", config.URL, name)
+}
@@ -0,0 +1,105 @@
+package site
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tdewolff/minify/v2"
+	"github.com/tdewolff/minify/v2/js"
+)
+
+/*
+ * A deliberately simple CSS minifier with no dependencies: it removes what
+ * is safe to remove without parsing the language properly. JavaScript
+ * takes a real parser to tell regular expressions, strings and template
+ * literals apart, so it goes through tdewolff/minify.
+ */
+
+/*
+ * Local names may be shortened, globals are kept so other scripts and
+ * inline handlers can still reach them
+ */
+func minifyJS(source []byte) ([]byte, error) {
+	var b bytes.Buffer
+	if err := js.Minify(minify.New(), &b, bytes.NewReader(source), nil); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+/*
+ * Drop comments and collapse whitespace, leaving quoted strings alone
+ */
+func minifyCSS(css string) string {
+	var b strings.Builder
+	space := false
+	semicolon := false // Held back until we know it doesn't end a block
+	flush := func() {
+		if semicolon {
+			b.WriteByte(';')
+			semicolon = false
+		}
+	}
+	for i := 0; i < len(css); i++ {
+		c := css[i]
+		switch {
+		case c == '"' || c == '\'':
+			// Copy the string through the closing quote
+			j := i + 1
+			for j < len(css) && css[j] != c {
+				if css[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(css) {
+				j = len(css) - 1
+			}
+			flush()
+			writeCSSSpace(&b, space, c)
+			space = false
+			b.WriteString(css[i : j+1])
+			i = j
+		case c == '/' && i+1 < len(css) && css[i+1] == '*':
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				flush()
+				return b.String()
+			}
+			i += end + 3
+			space = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			space = true
+		case c == ';':
+			semicolon = true
+			space = false
+		case c == '}':
+			// The last declaration in a block needs no semicolon
+			semicolon = false
+			space = false
+			b.WriteByte(c)
+		default:
+			flush()
+			writeCSSSpace(&b, space, c)
+			space = false
+			b.WriteByte(c)
+		}
+	}
+	flush()
+	return b.String()
+}
+
+/*
+ * Whitespace matters between words (`margin: 0 auto`) but not around
+ * punctuation
+ */
+func writeCSSSpace(b *strings.Builder, space bool, next byte) {
+	// A space before : is kept, `a :hover` differs from `a:hover`
+	if !space || b.Len() == 0 || strings.IndexByte("{};,>", next) >= 0 {
+		return
+	}
+	if last := b.String()[b.Len()-1]; strings.IndexByte("{};:,>", last) >= 0 {
+		return
+	}
+	b.WriteByte(' ')
+}
@@ -0,0 +1,64 @@
+package site
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMinifyCSS(t *testing.T) {
+	tests := []struct {
+		name     string
+		css      string
+		expected string
+	}{
+		{name: "Whitespace", css: "body {\n  margin: 0 auto;\n  color: red;\n}\n", expected: "body{margin:0 auto;color:red}"},
+		{name: "Comments", css: "/* reset */\na { color: blue; } /* done */", expected: "a{color:blue}"},
+		{name: "Strings kept", css: `a::after { content: "  /* not a comment */  "; }`, expected: `a::after{content:"  /* not a comment */  "}`},
+		{name: "Descendant pseudo-class", css: "nav :hover { color: red }", expected: "nav :hover{color:red}"},
+		{name: "Selectors", css: "ul > li,\nol > li { padding: 0 }", expected: "ul>li,ol>li{padding:0}"},
+		{name: "Unterminated comment", css: "a { color: red } /* oops", expected: "a{color:red}"},
+		{name: "Statements", css: "@import url(a.css);\n@charset \"utf-8\";", expected: `@import url(a.css);@charset "utf-8";`},
+		{name: "Semicolon before comment", css: "a { color: red; /* last */ }", expected: "a{color:red}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := minifyCSS(tt.css); actual != tt.expected {
+				t.Errorf("minifyCSS() = %q, expected %q", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMinifyJS(t *testing.T) {
+	tests := []struct {
+		name     string
+		js       string
+		expected string
+	}{
+		{name: "Template literal", js: "const help = `\n    // not a comment\n`;\n", expected: "const help=`\n    // not a comment\n`"},
+		{name: "Regular expression", js: "var re = /\\/\\/ x/g; // trailing\n", expected: `var re=/\/\/ x/g`},
+		{name: "Locals shortened", js: "function greet(name) {\n  let message = 'hi ' + name;\n  return message;\n}\n", expected: `function greet(e){let t="hi "+e;return t}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := minifyJS([]byte(tt.js))
+			if err != nil || string(actual) != tt.expected {
+				t.Errorf("minifyJS() = %q, %v, expected %q", actual, err, tt.expected)
+			}
+		})
+	}
+
+	if _, err := minifyJS([]byte("function (")); err == nil {
+		t.Errorf("expected an error for invalid JS")
+	}
+}
+
+func TestMinifyCSSLarge(t *testing.T) {
+	// Quadratic rewriting took seconds here
+	css := strings.Repeat("a { color: red; }\n", 200000)
+	if actual := minifyCSS(css); actual != strings.Repeat("a{color:red}", 200000) {
+		t.Errorf("minifyCSS() = %.40q...", actual)
+	}
+}
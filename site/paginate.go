@@ -0,0 +1,88 @@
+package site
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+/*
+ * Pagination for the home page and tag pages: page 1 stays where it always
+ * was and later pages go in <page>/<path>/2/, <page>/<path>/3/, ...
+ */
+
+const defaultPaginatePath = "page"
+
+type PaginateConfig struct {
+	Size int    `yaml:"size"` // Posts per page, 0 puts every post on one page
+	Path string `yaml:"path"` // Folder holding pages 2 onwards, defaults to "page"
+}
+
+type Paginator struct {
+	Page       int // 1-based
+	TotalPages int
+	TotalPosts int
+	URL        string // This page
+	PrevURL    string // Newer posts, empty on the first page
+	NextURL    string // Older posts, empty on the last page
+	FirstURL   string
+	LastURL    string
+	Posts      []Post // Posts on this page
+}
+
+func paginatePath(config Config) string {
+	if config.Paginate.Path == "" {
+		return defaultPaginatePath
+	}
+	return strings.Trim(config.Paginate.Path, "/")
+}
+
+/*
+ * Split posts into pages. baseURL is the URL of page 1 and ends in a slash.
+ * There is always at least one page, even with no posts.
+ */
+func paginate(config Config, posts []Post, baseURL string) []Paginator {
+	size := config.Paginate.Size
+	if size <= 0 || size > len(posts) {
+		size = max(len(posts), 1)
+	}
+	total := max((len(posts)+size-1)/size, 1)
+
+	pageURL := func(page int) string {
+		if page == 1 {
+			return baseURL
+		}
+		return fmt.Sprintf("%s%s/%d/", baseURL, paginatePath(config), page)
+	}
+
+	pages := make([]Paginator, total)
+	for i := range pages {
+		page := i + 1
+		pages[i] = Paginator{
+			Page:       page,
+			TotalPages: total,
+			TotalPosts: len(posts),
+			URL:        pageURL(page),
+			FirstURL:   pageURL(1),
+			LastURL:    pageURL(total),
+			Posts:      posts[min(i*size, len(posts)):min(page*size, len(posts))],
+		}
+		if page > 1 {
+			pages[i].PrevURL = pageURL(page - 1)
+		}
+		if page < total {
+			pages[i].NextURL = pageURL(page + 1)
+		}
+	}
+	return pages
+}
+
+/*
+ * Output file for a page, dir being the folder of page 1
+ */
+func paginatedFilePath(config Config, dir string, page int) string {
+	if page == 1 {
+		return filepath.Join(dir, "index.html")
+	}
+	return filepath.Join(dir, paginatePath(config), fmt.Sprint(page), "index.html")
+}
@@ -0,0 +1,102 @@
+package site
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	posts := make([]Post, 5)
+	tests := []struct {
+		name     string
+		size     int
+		posts    []Post
+		expected []int // Posts on each page
+	}{
+		{"Disabled", 0, posts, []int{5}},
+		{"Even split", 5, posts, []int{5}},
+		{"Remainder", 2, posts, []int{2, 2, 1}},
+		{"No posts", 2, nil, []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Paginate: PaginateConfig{Size: tt.size}}
+			pages := paginate(config, tt.posts, "https://example.com/")
+			if len(pages) != len(tt.expected) {
+				t.Fatalf("expected %d pages, got %d", len(tt.expected), len(pages))
+			}
+			for i, page := range pages {
+				if len(page.Posts) != tt.expected[i] || page.Page != i+1 || page.TotalPages != len(tt.expected) {
+					t.Errorf("page %d: unexpected %+v", i+1, page)
+				}
+			}
+			if pages[0].URL != "https://example.com/" || pages[0].PrevURL != "" {
+				t.Errorf("first page should live at the base URL, got %s", pages[0].URL)
+			}
+			if len(pages) > 1 && (pages[0].NextURL != "https://example.com/page/2/" || pages[1].PrevURL != pages[0].URL) {
+				t.Errorf("unexpected links %s, %s", pages[0].NextURL, pages[1].PrevURL)
+			}
+			if last := pages[len(pages)-1]; last.NextURL != "" || last.LastURL != last.URL {
+				t.Errorf("last page should not link onwards, got %s", last.NextURL)
+			}
+		})
+	}
+}
+
+func TestPaginatedPages(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md":  post("First", "first", "2025-01-01T10:00:00Z", "public"),
+		"20250102.second.md": post("Second", "second", "2025-01-02T10:00:00Z", "public"),
+		"20250103.third.md":  post("Third", "third", "2025-01-03T10:00:00Z", "public"),
+	})
+	s.Config.Paginate = PaginateConfig{Size: 2, Path: "p"}
+	tagTemplate := `{{ .Key }}:{{ range .Value }}{{ .FrontMatter.Title }},{{ end }}`
+	if err := os.WriteFile(filepath.Join(s.Config.TemplatesDir, "tag.html"), []byte(tagTemplate), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	expected := map[string]string{
+		"index.html":               "Third",
+		"p/2/index.html":           "First",
+		"tags/meta/index.html":     "meta:Third,Second,",
+		"tags/meta/p/2/index.html": "meta:First,",
+	}
+	for name, content := range expected {
+		actual, err := os.ReadFile(filepath.Join(s.Config.OutputDir, name))
+		if err != nil || !strings.Contains(string(actual), content) {
+			t.Errorf("%s: expected %q, got %q (%v)", name, content, actual, err)
+		}
+	}
+
+	sitemap, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "sitemap.xml"))
+	for _, url := range []string{"https://example.com/p/2/", "https://example.com/tags/meta/p/2/"} {
+		if !strings.Contains(string(sitemap), "<loc>"+url+"</loc>") {
+			t.Errorf("expected %s in sitemap", url)
+		}
+	}
+	// Each tag page was last modified by its newest post
+	for url, lastMod := range map[string]string{"https://example.com/tags/meta/": "2025-01-03T10:00:00Z", "https://example.com/tags/meta/p/2/": "2025-01-01T10:00:00Z"} {
+		entry := regexp.MustCompile(`<loc>` + regexp.QuoteMeta(url) + `</loc>\s*<lastmod>` + lastMod + `</lastmod>`)
+		if !entry.Match(sitemap) {
+			t.Errorf("expected %s to be last modified %s in %s", url, lastMod, sitemap)
+		}
+	}
+
+	// Shrinking the site removes pages that are no longer needed
+	s.Config.Paginate.Size = 3
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Config.OutputDir, "p", "2", "index.html")); !os.IsNotExist(err) {
+		t.Errorf("expected page 2 to be removed")
+	}
+}
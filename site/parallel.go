@@ -0,0 +1,72 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+)
+
+/*
+ * Number of workers used to render pages
+ */
+func (s *Site) workers() int {
+	if s.Jobs > 0 {
+		return s.Jobs
+	}
+	return runtime.NumCPU()
+}
+
+/*
+ * Run n jobs on a pool of workers. Each job writes its console output to
+ * its own buffer and the buffers are flushed in job order, so the log reads
+ * the same however the work was scheduled. The first job to fail cancels
+ * the rest; the error reported is the failing job with the lowest index.
+ */
+func (s *Site) parallel(ctx context.Context, n int, job func(ctx context.Context, i int, out io.Writer) error) error {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outputs := make([]bytes.Buffer, n)
+	errs := make([]error, n)
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(s.workers(), n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := jobCtx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				if err := job(jobCtx, i, &outputs[i]); err != nil {
+					errs[i] = err
+					cancel()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i := range outputs {
+		s.Log.Write(outputs[i].Bytes())
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestParallelOutputOrder(t *testing.T) {
+	var log bytes.Buffer
+	s := &Site{Log: &log, Jobs: 4}
+
+	err := s.parallel(context.Background(), 8, func(ctx context.Context, i int, out io.Writer) error {
+		// Finish the later jobs first
+		time.Sleep(time.Duration(8-i) * time.Millisecond)
+		fmt.Fprintf(out, "%d\n", i)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parallel() = %v", err)
+	}
+	if got, want := log.String(), "0\n1\n2\n3\n4\n5\n6\n7\n"; got != want {
+		t.Errorf("output out of order:\n%s", got)
+	}
+}
+
+func TestParallelError(t *testing.T) {
+	s := &Site{Log: io.Discard, Jobs: 2}
+	failure := errors.New("job 3 failed")
+
+	err := s.parallel(context.Background(), 10, func(ctx context.Context, i int, out io.Writer) error {
+		if i == 3 {
+			return failure
+		}
+		return nil
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("parallel() = %v; expected %v", err, failure)
+	}
+}
@@ -0,0 +1,744 @@
+package site
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+	"gopkg.in/yaml.v3"
+)
+
+type PostStatus string
+
+const (
+	Public   PostStatus = "public"
+	Private  PostStatus = "private"
+	Unlisted PostStatus = "unlisted" // Rendered but only reachable by URL
+	Draft    PostStatus = "draft"    // Like unlisted, but only rendered with --drafts
+)
+
+var ValidPostStatuses = map[PostStatus]struct{}{
+	Public:   {},
+	Private:  {},
+	Unlisted: {},
+	Draft:    {},
+}
+
+/*
+ * Shown on draft posts so a preview is never mistaken for the real thing
+ */
+const draftBanner = "Draft: this post has not been published yet"
+
+type FrontMatter struct {
+	Title       string    `yaml:"title"`
+	Link        string    `yaml:"link"`
+	Description string    `yaml:"description"`
+	Tags        []string  `yaml:"tags,omitempty"`
+	Image       string    `yaml:"image,omitempty"`
+	Alt         string    `yaml:"alt,omitempty"`
+	Published   string    `yaml:"published"`
+	Updated     string    `yaml:"updated,omitempty"`
+	Template    string    `yaml:"template"`
+	Favicon     string    `yaml:"favicon,omitempty"`
+	Author      string    `yaml:"author,omitempty"`
+	Email       string    `yaml:"email,omitempty"`
+	Status      string    `yaml:"status"`
+	Related     []string  `yaml:"related,omitempty"`
+	SummaryOnly bool      `yaml:"summary_only,omitempty"` // Keep the full post out of feeds
+	Enclosure   Enclosure `yaml:"enclosure,omitempty"`    // Attached media, e.g. a podcast episode
+	TOC         bool      `yaml:"toc,omitempty"`          // Build a table of contents, see toc.go
+	TOCMin      int       `yaml:"toc_min,omitempty"`      // Shallowest heading level in it, defaults to 2
+	TOCMax      int       `yaml:"toc_max,omitempty"`      // Deepest, defaults to 3
+}
+
+/*
+ * A media file attached to a post, published in feeds
+ */
+type Enclosure struct {
+	URL      string `yaml:"url"`
+	Type     string `yaml:"type"`               // MIME type e.g. audio/mpeg
+	Length   int64  `yaml:"length"`             // Size in bytes
+	Duration string `yaml:"duration,omitempty"` // Seconds, MM:SS or HH:MM:SS
+}
+
+var enclosureDuration = regexp.MustCompile(`^\d+(:[0-5]?\d){0,2}$`)
+
+type Tag struct {
+	TagName string // Display name
+	URL     string
+	Slug    string // Folder under /tags/, see tags.go
+	RSS     string // Feed URLs, empty unless feeds.per_tag is set
+	Atom    string
+}
+
+type Post struct {
+	FrontMatter FrontMatter
+	URL         string
+	HTML        string
+	Text        string          // Plaintext representation
+	WordCount   int             // Words of prose, see reading.go
+	ReadingTime int             // Minutes at reading.words_per_minute
+	Excerpt     string          // Plain text before <!--more-->, or the first reading.excerpt_words words
+	Summary     string          // Description, or the excerpt if the description is short
+	PubTime     time.Time       // parsed version of Published date
+	PubDate     string          // 15-Jan-2025
+	UpdatedTime time.Time       // Last update, PubTime if never updated, see updated.go
+	UpdatedDate string          // 15-Jan-2025, empty unless updated after publishing
+	Image       string          // FrontMatter.Image as an absolute URL, its largest variant if resized
+	ImageSet    ResponsiveImage // Variants of FrontMatter.Image, see images.go
+	Tags        []Tag
+	Related     []Post
+	Previous    []Post
+	Next        []Post
+
+	hash         string                     // Hash of front matter and content, see cache.go
+	path         string                     // Source file
+	bundle       string                     // Folder of a page bundle, see bundle.go
+	images       map[string]ResponsiveImage // Processed images by reference, see images.go
+	relatedLinks []string                   // Resolved `related` entries, see related.go
+}
+
+type PlainTextRenderer struct {
+	buf   bytes.Buffer
+	prose bool // Leave out link destinations and list bullets, see reading.go
+}
+
+/*
+ * Markdown to HTML. Hooks are tried in order for each node, the first to
+ * handle a node wins.
+ */
+func publish(md []byte, hooks ...html.RenderNodeFunc) []byte {
+	return render(parseMarkdown(md), hooks...)
+}
+
+func parseMarkdown(md []byte) ast.Node {
+	extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.NoEmptyLineBeforeBlock
+	return parser.NewWithExtensions(extensions).Parse(md)
+}
+
+func render(doc ast.Node, hooks ...html.RenderNodeFunc) []byte {
+	htmlFlags := html.CommonFlags | html.HrefTargetBlank
+	opts := html.RendererOptions{Flags: htmlFlags}
+	if len(hooks) > 0 {
+		opts.RenderNodeHook = func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+			for _, hook := range hooks {
+				if status, handled := hook(w, node, entering); handled {
+					return status, true
+				}
+			}
+			return ast.GoToNext, false
+		}
+	}
+	renderer := html.NewRenderer(opts)
+
+	return markdown.Render(doc, renderer)
+}
+
+/*
+ * filePath = path to a post
+ *
+ * returns: frontMatter, content, err
+ */
+func parseFileWithHeaders(filePath string) (*FrontMatter, string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open file '%s': %w", filePath, err)
+	}
+	defer file.Close()
+
+	var frontMatter FrontMatter
+	var contentBuilder strings.Builder
+
+	// Read the file and extract front matter and content
+	var frontMatterBuilder strings.Builder
+	inFrontMatter := false
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "---" {
+			inFrontMatter = !inFrontMatter
+			continue
+		}
+
+		if inFrontMatter {
+			frontMatterBuilder.WriteString(line + "\n")
+		} else {
+			contentBuilder.WriteString(line + "\n")
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to read file '%s': %w", filePath, err)
+	}
+
+	// Parse front matter as YAML
+	yamlContent := frontMatterBuilder.String()
+	if err := yaml.Unmarshal([]byte(yamlContent), &frontMatter); err != nil {
+		return nil, "", fmt.Errorf("failed to parse front matter: %w\nYAML:\n%s", err, yamlContent)
+	}
+
+	// Trim leading newline from content
+	content := strings.TrimPrefix(contentBuilder.String(), "\n")
+	return &frontMatter, content, nil
+}
+
+func validateHeaders(frontMatter FrontMatter, filePath string) error {
+	var errorMessages []string
+
+	// Check for missing required headers
+	if frontMatter.Title == "" {
+		errorMessages = append(errorMessages, "missing a required header: title")
+	}
+	if frontMatter.Link == "" {
+		errorMessages = append(errorMessages, "missing a required header: link")
+	}
+	if frontMatter.Published == "" {
+		errorMessages = append(errorMessages, "missing a required header: published")
+	}
+	if frontMatter.Template == "" {
+		errorMessages = append(errorMessages, "missing a required header: template")
+	}
+	if frontMatter.Description == "" {
+		errorMessages = append(errorMessages, "missing a required header: description")
+	}
+
+	// An enclosure needs everything RSS requires
+	if frontMatter.Enclosure != (Enclosure{}) {
+		if frontMatter.Enclosure.URL == "" {
+			errorMessages = append(errorMessages, "missing a required header: enclosure.url")
+		}
+		if frontMatter.Enclosure.Type == "" {
+			errorMessages = append(errorMessages, "missing a required header: enclosure.type")
+		}
+		if frontMatter.Enclosure.Length <= 0 {
+			errorMessages = append(errorMessages, "missing a required header: enclosure.length")
+		}
+		if d := frontMatter.Enclosure.Duration; d != "" && !enclosureDuration.MatchString(d) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Invalid value for enclosure.duration: %s", d))
+		}
+	}
+
+	errorMessages = append(errorMessages, validateTOCLevels(frontMatter)...)
+
+	// Validate "status" field
+	if _, valid := ValidPostStatuses[PostStatus(frontMatter.Status)]; !valid {
+		errorMessages = append(errorMessages, fmt.Sprintf("Invalid value for status: %s", frontMatter.Status))
+	}
+
+	// Aggregate and return errors
+	if len(errorMessages) > 0 {
+		return &ValidationError{Path: filePath, Problems: errorMessages}
+	}
+
+	return nil
+}
+
+/*
+ * Sanity check: Validating user-provided link names
+ */
+
+func validateLinkName(link string) error {
+	// Disallow reserved names
+	if link == "." || link == ".." {
+		return errors.New("invalid name: '.' and '..' are not allowed")
+	}
+
+	// Disallow path traversal patterns
+	if strings.Contains(link, "..") {
+		return errors.New("invalid name: path traversal patterns like '..' are not allowed")
+	}
+
+	// Disallow illegal characters
+	illegalChars := regexp.MustCompile(`[<>:"/\\|?*\n\r\t]`)
+	if illegalChars.MatchString(link) {
+		return errors.New("invalid name: contains illegal characters (e.g., < > : \" / \\ | ? *)")
+	}
+
+	// Check for leading/trailing whitespace
+	if strings.TrimSpace(link) != link {
+		return errors.New("invalid name: leading or trailing whitespace is not allowed")
+	}
+
+	// Ensure the name is not empty and is of reasonable length
+	if len(link) == 0 || len(link) > 255 {
+		return errors.New("invalid name: must be between 1 and 255 characters long")
+	}
+
+	return nil
+}
+
+/*
+ * Turn free text into a link name: lowercase letters and digits, in any
+ * script, separated by single dashes, e.g. "Hello, World!" => "hello-world"
+ * and "Ünïcode" => "ünïcode"
+ */
+func slugify(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		// Combining marks belong to the letter before them, as in decomposed é
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	slug := b.String()
+	if len(slug) > 200 {
+		// Don't cut a letter in half
+		end := 200
+		for !utf8.RuneStart(slug[end]) {
+			end--
+		}
+		slug = strings.TrimRight(slug[:end], "-")
+	}
+	return slug
+}
+
+func reverse(posts []Post) []Post {
+	reversed := make([]Post, len(posts))
+	for i, post := range posts {
+		reversed[len(posts)-1-i] = post
+	}
+	return reversed
+}
+
+/*
+ * Helper code for transforming Markdown to text/plain for indexing purposes
+ */
+
+func (r *PlainTextRenderer) RenderNode(w io.Writer, node ast.Node, entering bool) ast.WalkStatus {
+	switch n := node.(type) {
+	case *ast.Text:
+		r.buf.Write(n.Literal)
+	case *ast.Link:
+		if !entering && !r.prose {
+			r.buf.WriteString(" (" + string(n.Destination) + ")")
+		}
+	case *ast.Heading:
+		if !entering {
+			r.buf.WriteString("\n")
+		}
+	case *ast.Paragraph:
+		if !entering {
+			r.buf.WriteString("\n\n")
+		}
+	case *ast.Code:
+		r.buf.Write(n.Literal)
+	case *ast.CodeBlock:
+		r.buf.Write(n.Literal)
+		r.buf.WriteString("\n")
+	case *ast.ListItem:
+		if entering && !r.prose {
+			r.buf.WriteString("- ")
+		}
+	case *ast.HTMLBlock, *ast.HTMLSpan:
+		return ast.SkipChildren
+	}
+	return ast.GoToNext
+}
+
+func (r *PlainTextRenderer) RenderHeader(w io.Writer, ast ast.Node) {}
+
+func (r *PlainTextRenderer) RenderFooter(w io.Writer, ast ast.Node) {}
+
+func ToPlainText(md string) string {
+	return plainText(md, false)
+}
+
+func plainText(md string, prose bool) string {
+	parser := parser.NewWithExtensions(parser.CommonExtensions)
+	doc := markdown.Parse([]byte(md), parser)
+	renderer := &PlainTextRenderer{prose: prose}
+	markdown.Render(doc, renderer)
+	return renderer.buf.String()
+}
+
+/*
+ * Pre-process all posts so we can show back/next
+ *
+ * returns: public posts new to old, unlisted posts (and drafts with
+ * --drafts) which are rendered but not listed anywhere, posts by link,
+ * posts by tag
+ */
+func (s *Site) loadPosts(ctx context.Context, files []fs.DirEntry) ([]Post, []Post, map[string]Post, map[Tag][]Post, error) {
+	/*
+	 * List of all posts
+	 */
+	var posts []Post
+	var unlisted []Post
+
+	/*
+	 * Map of one tag to many posts
+	 */
+	tagIndex := make(map[Tag][]Post)
+
+	postIndex := make(map[string]Post)
+	reserved := reservedLinks(s.Config)
+	relatedIndex := newRelatedIndex()
+	now := time.Now()
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, nil, nil, err
+		}
+		filePath := postSource(s.Config.InputDir, file)
+		if filePath == "" {
+			continue
+		}
+		post, err := s.loadPost(filePath)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		if file.IsDir() {
+			post.bundle = filepath.Dir(filePath)
+		}
+
+		/*
+		 * Skip private posts
+		 */
+		status := PostStatus(post.FrontMatter.Status)
+		scheduled := !s.Future && post.PubTime.After(now)
+		relatedIndex.add(file.Name(), post.FrontMatter.Link, status != Public || scheduled)
+		if status == Private {
+			s.logf("📕 Post: %s [private] skipping...\n", post.FrontMatter.Link)
+			s.result.Skipped = append(s.result.Skipped, post.FrontMatter.Link)
+			continue
+		}
+
+		/*
+		 * Skip drafts unless previewing them
+		 */
+		if status == Draft && !s.Drafts {
+			s.logf("📕 Post: %s [draft] skipping...\n", post.FrontMatter.Link)
+			s.result.Skipped = append(s.result.Skipped, post.FrontMatter.Link)
+			continue
+		}
+
+		/*
+		 * Skip posts scheduled for later
+		 */
+		if scheduled {
+			s.logf("📕 Post: %s [scheduled for %s] skipping...\n", post.FrontMatter.Link, post.PubTime.Format(time.RFC3339))
+			s.result.Skipped = append(s.result.Skipped, post.FrontMatter.Link)
+			continue
+		}
+
+		/*
+		 * Check for duplicate links in posts
+		 */
+		if _, ok := postIndex[post.FrontMatter.Link]; ok || reserved[post.FrontMatter.Link] {
+			return nil, nil, nil, nil, &DuplicateLinkError{Link: post.FrontMatter.Link, Path: filepath.Join(s.Config.InputDir, file.Name())}
+		}
+		if err := s.processImages(&post); err != nil {
+			return nil, nil, nil, nil, err
+		}
+		postIndex[post.FrontMatter.Link] = post
+
+		if status == Public {
+			posts = append(posts, post)
+		} else {
+			unlisted = append(unlisted, post)
+		}
+	}
+
+	/*
+	 * Check for duplicate links across pages
+	 */
+	pageLinks := make(map[string]bool)
+	for _, page := range s.Config.Pages {
+		if _, ok := postIndex[page.Link]; ok || reserved[page.Link] || pageLinks[page.Link] {
+			return nil, nil, nil, nil, &DuplicateLinkError{Link: page.Link}
+		}
+		pageLinks[page.Link] = true
+	}
+
+	if err := s.resolveRelated(posts, relatedIndex); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := s.resolveRelated(unlisted, relatedIndex); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	/*
+	 * Display new to old, tag pages and feeds included
+	 */
+	posts = reverse(posts)
+	for _, post := range posts {
+		postIndex[post.FrontMatter.Link] = post
+		for _, tag := range post.Tags {
+			tagIndex[tag] = append(tagIndex[tag], post)
+		}
+	}
+
+	/*
+	 * Unlisted posts don't appear on tag pages, so only link the tags
+	 * that have a page of their own
+	 */
+	for i := range unlisted {
+		post := &unlisted[i]
+		var tags []Tag
+		for _, tag := range post.Tags {
+			if _, ok := tagIndex[tag]; ok {
+				tags = append(tags, tag)
+			}
+		}
+		post.Tags = tags
+		postIndex[post.FrontMatter.Link] = *post
+	}
+
+	return posts, unlisted, postIndex, tagIndex, nil
+}
+
+func (s *Site) loadPost(filePath string) (Post, error) {
+	config := s.Config
+	frontMatter, content, err := parseFileWithHeaders(filePath)
+	if err != nil {
+		return Post{}, &PostError{Path: filePath, Err: err}
+	}
+
+	if err := validateHeaders(*frontMatter, filePath); err != nil {
+		return Post{}, err
+	}
+
+	if err := validateLinkName(frontMatter.Link); err != nil {
+		return Post{}, &PostError{Path: filePath, Err: err}
+	}
+
+	// make tag structs: tag name, URL, slug
+	var tags []Tag
+	for _, name := range frontMatter.Tags {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		tag, err := s.tags.tag(name)
+		if err != nil {
+			return Post{}, &PostError{Path: filePath, Err: err}
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	pubTime, err := time.Parse(time.RFC3339, frontMatter.Published)
+	if err != nil {
+		return Post{}, &PostError{Path: filePath, Err: fmt.Errorf("error parsing date: %w", err)}
+	}
+
+	updatedTime, err := s.updatedTime(filePath, *frontMatter, pubTime)
+	if err != nil {
+		return Post{}, &PostError{Path: filePath, Err: err}
+	}
+	var updatedDate string
+	if updatedTime.After(pubTime) {
+		updatedDate = updatedTime.Format("02-Jan-2006")
+	}
+
+	/*
+	 * Reuse the plaintext rendering from the last build if the post is unchanged
+	 */
+	// A new version of draft may render the same Markdown differently
+	hash := hashKey(s.Version, fmt.Sprintf("%#v", *frontMatter), content, updatedTime.String(), fmt.Sprintf("%#v", config.Highlight), fmt.Sprint(config.Reading.ExcerptWords))
+	cached, ok := s.cache.post(hash)
+	if !ok {
+		cached.Text = ToPlainText(content)
+		cached.WordCount, cached.Excerpt = excerpt(content, config.Reading.ExcerptWords)
+	}
+	s.cache.setPost(hash, cached)
+
+	url := buildPostLink(config, frontMatter.Link)
+	post := Post{
+		FrontMatter: *frontMatter,
+		URL:         url,
+		Image:       resolveURL(frontMatter.Image, url),
+		HTML:        content,
+		Text:        cached.Text,
+		WordCount:   cached.WordCount,
+		ReadingTime: readingTime(cached.WordCount, config.Reading.WordsPerMinute),
+		Excerpt:     cached.Excerpt,
+		Summary:     summary(frontMatter.Description, cached.Excerpt, config.Reading.ShortDescription),
+		PubDate:     pubTime.Format("02-Jan-2006"),
+		PubTime:     pubTime,
+		UpdatedTime: updatedTime,
+		UpdatedDate: updatedDate,
+		Tags:        tags,
+		hash:        hash,
+		path:        filePath,
+	}
+
+	return post, nil
+}
+
+/*
+ * Markdown to HTML and the table of contents, reusing the last build's
+ * rendering of an unchanged post
+ */
+func (s *Site) publishPost(post Post) (template.HTML, TOC) {
+	cached, _ := s.cache.post(post.hash)
+	if cached.HTML == "" {
+		doc := parseMarkdown([]byte(post.HTML))
+		cached.TOC = buildTOC(doc, post.FrontMatter)
+		hooks := []html.RenderNodeFunc{imageHook(post.images), tocHook(cached.TOC)}
+		if s.Config.Highlight.Enabled {
+			hooks = append(hooks, highlightHook(s.Config.Highlight))
+		}
+		cached.HTML = string(render(doc, hooks...))
+		s.cache.setPost(post.hash, cached)
+	}
+	return template.HTML(cached.HTML), cached.TOC
+}
+
+/*
+ * Convert each post from Markdown to HTML. Unlisted posts are rendered on
+ * their own, without previous/next links.
+ */
+func (s *Site) renderPosts(ctx context.Context, posts []Post, unlisted []Post, postIndex map[string]Post) error {
+	return s.parallel(ctx, len(posts)+len(unlisted), func(ctx context.Context, i int, out io.Writer) error {
+		if i >= len(posts) {
+			i -= len(posts)
+			return s.renderPost(unlisted[i:i+1], 0, postIndex, out)
+		}
+		return s.renderPost(posts, i, postIndex, out)
+	})
+}
+
+func (s *Site) renderPost(posts []Post, i int, postIndex map[string]Post, out io.Writer) error {
+	config := s.Config
+	post := posts[i]
+	tmpl, templateKey, err := s.parseTemplate(filepath.Join(config.TemplatesDir, post.FrontMatter.Template))
+	if err != nil {
+		return err
+	}
+
+	/*
+	 * Determine previous/next posts
+	 */
+	if i != len(posts)-1 {
+		post.Previous = append(post.Previous, posts[i+1])
+	}
+	if i > 0 {
+		post.Next = append(post.Next, posts[i-1])
+	}
+
+	// transform list of related posts by label to a `Related` struct
+	var related []Post
+	for _, link := range post.relatedLinks {
+		related = append(related, postIndex[link])
+	}
+	post.Related = related
+
+	if err := s.copyBundle(post, out); err != nil {
+		return err
+	}
+	card, err := s.generateSocialCard(post, out)
+	if err != nil {
+		return err
+	}
+
+	/*
+	 * Skip the post if neither it, its neighbors nor the templates changed.
+	 * Its resolved tags count too: display names and which tags have a page
+	 * depend on other posts.
+	 */
+	outputFilePath := filepath.Join(config.OutputDir, post.FrontMatter.Link, "index.html")
+	key := hashKey(s.siteKey, templateKey, post.hash, fmt.Sprintf("%#v", post.Tags), postsKey(post.Previous), postsKey(post.Next), postsKey(post.Related))
+	if s.unchanged(outputFilePath, key) {
+		return nil
+	}
+
+	labels := Labels{
+		Title: post.FrontMatter.Title,
+	}
+
+	/*
+	 * Collect all tags as we go
+	 */
+	var tags []Tag
+	tags = append(tags, post.Tags...)
+
+	/*
+	 * Generate list of tag names
+	 */
+	tagNames := make([]string, len(tags))
+	for i, tag := range tags {
+		tagNames[i] = tag.TagName
+	}
+
+	unfurl := Unfurl{
+		Title:        post.FrontMatter.Title,
+		URL:          post.URL,
+		Author:       post.FrontMatter.Author,
+		Description:  post.FrontMatter.Description,
+		SiteName:     config.BlogName,
+		Tags:         strings.Join(tagNames, ","),
+		Locale:       config.Locale,
+		ModifiedTime: post.UpdatedTime.Format(time.RFC3339),
+		Image:        post.Image,
+	}
+	if unfurl.Image == "" {
+		unfurl.Image = card
+	}
+
+	status := PostStatus(post.FrontMatter.Status)
+	var banner string
+	if status == Draft {
+		banner = draftBanner
+	}
+
+	content, toc := s.publishPost(post)
+
+	/*
+	 * Template variables
+	 */
+	data := map[string]interface{}{
+		"Config":    config,
+		"Labels":    labels,
+		"Unfurl":    unfurl,
+		"Post":      post,
+		"Content":   content,
+		"TOC":       toc,
+		"Tags":      tags,
+		"Version":   s.Version,
+		"Now":       s.now,
+		"Canonical": post.URL,
+		"Links":     s.links,
+		"Badges":    s.badges,
+		"Banner":    banner,           // Empty unless the post is a draft
+		"NoIndex":   status != Public, // Keep unlisted posts and drafts out of search engines
+	}
+
+	/*
+	 * Write post to disk with folder name specified as `link` in metadata
+	 */
+	if err := s.execute(tmpl, outputFilePath, data); err != nil {
+		return err
+	}
+
+	if status == Public {
+		fmt.Fprintf(out, "📘 Post: \"%s\" by %s\n", post.FrontMatter.Link, post.FrontMatter.Author)
+	} else {
+		fmt.Fprintf(out, "📘 Post: \"%s\" by %s [%s]\n", post.FrontMatter.Link, post.FrontMatter.Author, status)
+	}
+	return nil
+}
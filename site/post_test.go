@@ -1,4 +1,4 @@
-package main
+package site
 
 import (
 	"sort"
@@ -153,6 +153,40 @@ missing a required header: published
 missing a required header: template
 Invalid value for status: `,
 		},
+		// Test 7: Valid enclosure
+		{
+			name: "Enclosure",
+			frontMatter: FrontMatter{
+				Title:       "Episode 1",
+				Link:        "episode-1",
+				Published:   "2024-12-17",
+				Template:    "default",
+				Description: "A podcast episode",
+				Status:      "public",
+				Enclosure:   Enclosure{URL: "https://example.com/ep1.mp3", Type: "audio/mpeg", Length: 1024, Duration: "1:02:03"},
+			},
+			filePath:   "post7.yaml",
+			shouldFail: false,
+		},
+		// Test 8: Incomplete enclosure
+		{
+			name: "Incomplete enclosure",
+			frontMatter: FrontMatter{
+				Title:       "Episode 1",
+				Link:        "episode-1",
+				Published:   "2024-12-17",
+				Template:    "default",
+				Description: "A podcast episode",
+				Status:      "public",
+				Enclosure:   Enclosure{URL: "https://example.com/ep1.mp3", Duration: "an hour"},
+			},
+			filePath:   "post8.yaml",
+			shouldFail: true,
+			expectedError: `Post post8.yaml has the following issues:
+missing a required header: enclosure.type
+missing a required header: enclosure.length
+Invalid value for enclosure.duration: an hour`,
+		},
 	}
 
 	for i, tt := range tests {
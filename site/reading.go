@@ -0,0 +1,62 @@
+package site
+
+import (
+	"strings"
+)
+
+/*
+ * Word count, reading time and excerpt of a post. The excerpt is the text
+ * before a <!--more--> marker, or the first words of the post.
+ */
+
+type ReadingConfig struct {
+	WordsPerMinute   int `yaml:"words_per_minute"`  // Reading speed, defaults to 200
+	ExcerptWords     int `yaml:"excerpt_words"`     // Length of an excerpt without a marker, defaults to 50
+	ShortDescription int `yaml:"short_description"` // Descriptions under this many words give way to the excerpt in Summary, unset never
+}
+
+const (
+	moreMarker            = "<!--more-->"
+	defaultWordsPerMinute = 200
+	defaultExcerptWords   = 50
+)
+
+/*
+ * Words of the post's prose, without link destinations or list bullets
+ *
+ * returns: word count, excerpt
+ */
+func excerpt(md string, words int) (int, string) {
+	if words == 0 {
+		words = defaultExcerptWords
+	}
+	all := strings.Fields(plainText(md, true))
+	if before, _, ok := strings.Cut(md, moreMarker); ok {
+		return len(all), strings.Join(strings.Fields(plainText(before, true)), " ")
+	}
+	if len(all) <= words {
+		return len(all), strings.Join(all, " ")
+	}
+	return len(all), strings.Join(all[:words], " ") + "…"
+}
+
+/*
+ * Minutes, rounded up. Only an empty post takes no time at all.
+ */
+func readingTime(wordCount, wordsPerMinute int) int {
+	if wordsPerMinute == 0 {
+		wordsPerMinute = defaultWordsPerMinute
+	}
+	return (wordCount + wordsPerMinute - 1) / wordsPerMinute
+}
+
+/*
+ * The description, unless it is too short to say much and there is an
+ * excerpt to stand in for it
+ */
+func summary(description, excerpt string, shortDescription int) string {
+	if excerpt != "" && len(strings.Fields(description)) < shortDescription {
+		return excerpt
+	}
+	return description
+}
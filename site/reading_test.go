@@ -0,0 +1,92 @@
+package site
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name      string
+		md        string
+		words     int
+		wordCount int
+		excerpt   string
+	}{
+		{name: "Short", md: "## Hello\n\nA *short* post.\n", words: 10, wordCount: 4, excerpt: "Hello A short post."},
+		{name: "Truncated", md: "one two three four five\n", words: 3, wordCount: 5, excerpt: "one two three…"},
+		{name: "Links and lists", md: "See [the docs](https://example.com/docs).\n\n- one\n- two\n", words: 10, wordCount: 5, excerpt: "See the docs. one two"},
+		{name: "Marker", md: "First paragraph.\n\n<!--more-->\n\nThe rest of it.\n", words: 1, wordCount: 6, excerpt: "First paragraph."},
+		{name: "Default length", md: strings.Repeat("word ", 60), words: 0, wordCount: 60, excerpt: strings.TrimSpace(strings.Repeat("word ", 50)) + "…"},
+		{name: "Empty", md: "", words: 10, wordCount: 0, excerpt: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wordCount, excerpt := excerpt(tt.md, tt.words)
+			if wordCount != tt.wordCount || excerpt != tt.excerpt {
+				t.Errorf("excerpt() = %d, %q, expected %d, %q", wordCount, excerpt, tt.wordCount, tt.excerpt)
+			}
+		})
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	tests := []struct {
+		wordCount      int
+		wordsPerMinute int
+		expected       int
+	}{
+		{wordCount: 0, wordsPerMinute: 0, expected: 0},
+		{wordCount: 1, wordsPerMinute: 0, expected: 1},
+		{wordCount: 200, wordsPerMinute: 0, expected: 1},
+		{wordCount: 201, wordsPerMinute: 0, expected: 2},
+		{wordCount: 1000, wordsPerMinute: 250, expected: 4},
+	}
+
+	for _, tt := range tests {
+		if actual := readingTime(tt.wordCount, tt.wordsPerMinute); actual != tt.expected {
+			t.Errorf("readingTime(%d, %d) = %d, expected %d", tt.wordCount, tt.wordsPerMinute, actual, tt.expected)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md": post("First", "first", "2025-01-01T10:00:00Z", "public") + "\n<!--more-->\n\nRead on.\n",
+	})
+	s.Config.Reading = ReadingConfig{ShortDescription: 3}
+	s.Config.Search = SearchConfig{Enabled: true, Dir: "search", Path: filepath.Join(s.Config.OutputDir, "sluggo.json")}
+	templates := map[string]string{
+		"index.html":  `{{ range .Posts }}{{ .WordCount }}|{{ .ReadingTime }}|{{ .Summary }}{{ end }}`,
+		"search.html": `search`,
+	}
+	for name, content := range templates {
+		if err := os.WriteFile(filepath.Join(s.Config.TemplatesDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := s.Build(context.Background())
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if post := result.Posts[0]; post.Excerpt != "Hello World Welcome to my blog." || post.Summary != post.Excerpt {
+		t.Errorf("expected the excerpt to stand in for a two word description, got %+v", post)
+	}
+
+	expected := map[string]string{
+		"index.html":  "8|1|Hello World Welcome to my blog.",
+		"rss.xml":     "<description>Hello World Welcome to my blog.</description>",
+		"sluggo.json": `"Description": "Hello World Welcome to my blog."`,
+	}
+	for name, content := range expected {
+		page, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, name))
+		if !strings.Contains(string(page), content) {
+			t.Errorf("%s: expected %s in %s", name, content, page)
+		}
+	}
+}
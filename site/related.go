@@ -0,0 +1,113 @@
+package site
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+/*
+ * Resolving `related` entries: a post may be named by its link or by its
+ * file name, with or without the .md extension
+ */
+
+type relatedIndex struct {
+	byLink  map[string]bool   // Every link
+	byFile  map[string]string // File name => link
+	private map[string]bool   // Links of posts that aren't public, or are scheduled
+}
+
+func newRelatedIndex() *relatedIndex {
+	return &relatedIndex{
+		byLink:  make(map[string]bool),
+		byFile:  make(map[string]string),
+		private: make(map[string]bool),
+	}
+}
+
+func (r *relatedIndex) add(fileName, link string, private bool) {
+	r.byLink[link] = true
+	r.byFile[fileName] = link
+	r.byFile[strings.TrimSuffix(fileName, ".md")] = link
+	if private {
+		r.private[link] = true
+	}
+}
+
+/*
+ * returns: link of the post named by ref, false if there is none
+ */
+func (r *relatedIndex) lookup(ref string) (string, bool) {
+	if r.byLink[ref] {
+		return ref, true
+	}
+	link, ok := r.byFile[ref]
+	return link, ok
+}
+
+/*
+ * returns: link of the post named by ref, err if it is missing or private
+ */
+func (r *relatedIndex) resolve(ref string) (string, error) {
+	link, ok := r.lookup(ref)
+	if !ok {
+		return "", fmt.Errorf("related post %s does not exist", ref)
+	}
+	if r.private[link] {
+		return "", fmt.Errorf("related post %s is not published", ref)
+	}
+	return link, nil
+}
+
+/*
+ * Replace each post's `related` entries with the links they resolve to.
+ * Unresolvable entries fail the build when related.strict is set and are
+ * dropped with a warning otherwise. With related.bidirectional, A => B also
+ * lists A on B.
+ */
+func (s *Site) resolveRelated(posts []Post, index *relatedIndex) error {
+	config := s.Config
+	position := make(map[string]int)
+	for i, post := range posts {
+		position[post.FrontMatter.Link] = i
+	}
+
+	for i := range posts {
+		post := &posts[i]
+		post.relatedLinks = nil
+		for _, ref := range post.FrontMatter.Related {
+			link, err := index.resolve(ref)
+			if err != nil {
+				if config.Related.Strict {
+					return &PostError{Path: post.path, Err: err}
+				}
+				s.logf("⚠️  Post: %s %v, skipping...\n", post.FrontMatter.Link, err)
+				continue
+			}
+			if !slices.Contains(post.relatedLinks, link) {
+				post.relatedLinks = append(post.relatedLinks, link)
+			}
+		}
+	}
+
+	if config.Related.Bidirectional {
+		// Snapshot first so back-references aren't themselves reflected
+		explicit := make([][]string, len(posts))
+		for i, post := range posts {
+			explicit[i] = slices.Clone(post.relatedLinks)
+		}
+		for i, links := range explicit {
+			for _, link := range links {
+				j, ok := position[link]
+				if !ok {
+					continue
+				}
+				other := &posts[j]
+				if link != posts[i].FrontMatter.Link && !slices.Contains(other.relatedLinks, posts[i].FrontMatter.Link) {
+					other.relatedLinks = append(other.relatedLinks, posts[i].FrontMatter.Link)
+				}
+			}
+		}
+	}
+	return nil
+}
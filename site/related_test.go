@@ -0,0 +1,77 @@
+package site
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func withRelated(content string, related ...string) string {
+	header := "related:\n"
+	for _, ref := range related {
+		header += "  - " + ref + "\n"
+	}
+	return strings.Replace(content, "---\n\n", header+"---\n\n", 1)
+}
+
+func TestRelated(t *testing.T) {
+	tests := []struct {
+		name          string
+		related       []string
+		strict        bool
+		bidirectional bool
+		expectError   bool
+		second        string // Related link expected on the second post, if any
+	}{
+		{name: "By link", related: []string{"second"}},
+		{name: "By file name", related: []string{"20250102.second.md"}},
+		{name: "By file name without extension", related: []string{"20250102.second"}},
+		{name: "Missing post dropped", related: []string{"second", "missing"}},
+		{name: "Private post dropped", related: []string{"second", "hidden"}},
+		{name: "Missing post strict", related: []string{"missing"}, strict: true, expectError: true},
+		{name: "Private post strict", related: []string{"hidden"}, strict: true, expectError: true},
+		{name: "Bidirectional", related: []string{"second"}, bidirectional: true, second: `<a href="https://example.com/first/">First</a>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSite(t, map[string]string{
+				"20250101.first.md":  withRelated(post("First", "first", "2025-01-01T10:00:00Z", "public"), tt.related...),
+				"20250102.second.md": post("Second", "second", "2025-01-02T10:00:00Z", "public"),
+				"20250103.hidden.md": post("Hidden", "hidden", "2025-01-03T10:00:00Z", "private"),
+			})
+			s.Config.Related = RelatedConfig{Strict: tt.strict, Bidirectional: tt.bidirectional}
+
+			_, err := s.Build(context.Background())
+			if tt.expectError {
+				var postErr *PostError
+				if !errors.As(err, &postErr) {
+					t.Fatalf("expected a PostError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build() = %v", err)
+			}
+
+			first, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "first", "index.html"))
+			if !strings.Contains(string(first), `<a href="https://example.com/second/">Second</a>`) {
+				t.Errorf("expected first to link to second, got %s", first)
+			}
+			if strings.Contains(string(first), `<a href="">`) {
+				t.Errorf("expected no empty related links, got %s", first)
+			}
+
+			second, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "second", "index.html"))
+			if tt.second != "" && !strings.Contains(string(second), tt.second) {
+				t.Errorf("expected second to contain %s, got %s", tt.second, second)
+			}
+			if tt.second == "" && strings.Contains(string(second), "<a href") {
+				t.Errorf("expected second to have no related posts, got %s", second)
+			}
+		})
+	}
+}
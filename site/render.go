@@ -0,0 +1,381 @@
+package site
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type Labels struct {
+	Title string
+}
+
+type Unfurl struct {
+	Title        string
+	URL          string
+	Author       string
+	Description  string
+	SiteName     string
+	Tags         string
+	Locale       string
+	ModifiedTime string // article:modified_time of a post
+	Image        string // og:image of a post
+}
+
+type Links struct {
+	Home     string
+	Tags     string
+	RSS      string
+	Atom     string
+	JSONFeed string
+	Sitemap  string
+	Rights   string
+	Archive  string // Empty unless archive pages are enabled
+}
+
+func loadBadges(badgesDir string) (map[string]template.HTML, error) {
+	badges := make(map[string]template.HTML)
+
+	badgeFiles, err := os.ReadDir(badgesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory '%s': %w", badgesDir, err)
+	}
+
+	for _, badgeFile := range badgeFiles {
+		if badgeFile.IsDir() {
+			continue
+		}
+		badgePath := filepath.Join(badgesDir, badgeFile.Name())
+		content, err := os.ReadFile(badgePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		badges[badgeFile.Name()] = template.HTML(string(content))
+	}
+
+	return badges, nil
+}
+
+/*
+ * Parse a page template together with the shared header and footer. Each
+ * template is parsed once per build and shared by every worker.
+ *
+ * returns: template, hash of its files, err
+ */
+func (s *Site) parseTemplate(templatePath string) (*template.Template, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tmpl, ok := s.templates[templatePath]; ok {
+		return tmpl, s.templateKeys[templatePath], nil
+	}
+
+	sharedPath := filepath.Join(s.Config.TemplatesDir, "shared.html")
+	tmpl, err := s.parseTemplateFiles(templatePath, sharedPath)
+	if err != nil {
+		return nil, "", &TemplateError{Template: templatePath, Err: fmt.Errorf("failed to parse template: %w", err)}
+	}
+	key, err := templateKey(templatePath, sharedPath)
+	if err != nil {
+		return nil, "", &TemplateError{Template: templatePath, Err: err}
+	}
+
+	s.templates[templatePath] = tmpl
+	s.templateKeys[templatePath] = key
+	return tmpl, key, nil
+}
+
+/*
+ * Functions available to every template
+ */
+func (s *Site) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"asset":     s.assetURL,
+		"integrity": s.assetIntegrity,
+	}
+}
+
+func (s *Site) parseTemplateFiles(paths ...string) (*template.Template, error) {
+	return template.New(filepath.Base(paths[0])).Funcs(s.templateFuncs()).ParseFiles(paths...)
+}
+
+/*
+ * Render a template to disk, creating the parent folder as needed
+ */
+func (s *Site) execute(tmpl *template.Template, outputFilePath string, data map[string]interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(outputFilePath), 0755); err != nil {
+		return &WriteError{Path: filepath.Dir(outputFilePath), Err: err}
+	}
+
+	outputFile, err := s.create(outputFilePath)
+	if err != nil {
+		return err
+	}
+	defer outputFile.Close()
+
+	if err := tmpl.Execute(outputFile, data); err != nil {
+		return &TemplateError{Template: tmpl.Name(), Err: fmt.Errorf("failed to execute template for file '%s': %w", outputFilePath, err)}
+	}
+	return nil
+}
+
+func (s *Site) generateIndexHTML(posts []Post) error {
+	config := s.Config
+	tmpl, templateKey, err := s.parseTemplate(config.IndexTemplatePath)
+	if err != nil {
+		return err
+	}
+
+	for _, page := range paginate(config, posts, buildRootLink(config)) {
+		indexFilePath := paginatedFilePath(config, config.OutputDir, page.Page)
+		if s.unchanged(indexFilePath, hashKey(s.siteKey, templateKey, postsKey(page.Posts), fmt.Sprint(page.Page, page.TotalPages))) {
+			continue
+		}
+
+		labels := Labels{
+			Title: config.BlogName,
+		}
+
+		url := page.URL
+
+		unfurl := Unfurl{
+			Title:       config.BlogName,
+			URL:         url,
+			Description: config.Description,
+			SiteName:    config.BlogName,
+			Locale:      config.Locale,
+		}
+
+		data := map[string]interface{}{
+			"Config":    config,
+			"Labels":    labels,
+			"Posts":     page.Posts,
+			"Paginator": page,
+			"Version":   s.Version,
+			"Now":       s.now,
+			"Canonical": url,
+			"Links":     s.links,
+			"Unfurl":    unfurl,
+			"Badges":    s.badges,
+		}
+
+		if err := s.execute(tmpl, indexFilePath, data); err != nil {
+			return err
+		}
+
+		s.logf("📙 Index: %s\n", indexFilePath)
+	}
+	return nil
+}
+
+/*
+ * Combined hash of every tag and the posts filed under it
+ */
+func tagsKey(tagIndex map[Tag][]Post) string {
+	tags := make([]Tag, 0, len(tagIndex))
+	for tag := range tagIndex {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Slug < tags[j].Slug
+	})
+	parts := make([]string, 0, 3*len(tags))
+	for _, tag := range tags {
+		parts = append(parts, tag.Slug, tag.TagName, postsKey(tagIndex[tag]))
+	}
+	return hashKey(parts...)
+}
+
+func (s *Site) generateTagsHTML(ctx context.Context, tagsOutputDir string, tagIndex map[Tag][]Post) error {
+	config := s.Config
+	tmpl, templateKey, err := s.parseTemplate(config.TagsIndexTemplatePath)
+	if err != nil {
+		return err
+	}
+
+	tagsIndexFilePath := filepath.Join(tagsOutputDir, "index.html")
+	if !s.unchanged(tagsIndexFilePath, hashKey(s.siteKey, templateKey, tagsKey(tagIndex))) {
+		if err := s.generateTagsIndexHTML(tmpl, tagsIndexFilePath, tagIndex); err != nil {
+			return err
+		}
+	}
+
+	tagPageTemplate, tagPageKey, err := s.parseTemplate(config.TagPageTemplatePath)
+	if err != nil {
+		return err
+	}
+
+	/*
+	 * Render tag pages in name order so the log is stable
+	 */
+	tags := make([]Tag, 0, len(tagIndex))
+	for tag := range tagIndex {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].TagName < tags[j].TagName
+	})
+
+	return s.parallel(ctx, len(tags), func(ctx context.Context, i int, out io.Writer) error {
+		tag := tags[i]
+		for _, page := range paginate(config, tagIndex[tag], tag.URL) {
+			tagFilePath := paginatedFilePath(config, filepath.Join(tagsOutputDir, tag.Slug), page.Page)
+			if s.unchanged(tagFilePath, hashKey(s.siteKey, tagPageKey, tag.Slug, tag.TagName, postsKey(page.Posts), fmt.Sprint(page.Page, page.TotalPages))) {
+				continue
+			}
+
+			labels := Labels{
+				Title: config.BlogName + " Tags",
+			}
+
+			url := page.URL
+
+			unfurl := Unfurl{
+				Title:       config.BlogName,
+				URL:         url,
+				Description: config.BlogName + ": Posts tagged " + tag.TagName,
+				SiteName:    config.BlogName,
+				Locale:      config.Locale,
+			}
+
+			data := map[string]interface{}{
+				"Config":    config,
+				"Labels":    labels,
+				"Key":       tag.TagName,
+				"Tag":       tag,
+				"Value":     page.Posts,
+				"Paginator": page,
+				"Version":   s.Version,
+				"Now":       s.now,
+				"Canonical": url,
+				"Links":     s.links,
+				"Unfurl":    unfurl,
+				"Badges":    s.badges,
+			}
+
+			if err := s.execute(tagPageTemplate, tagFilePath, data); err != nil {
+				return err
+			}
+			if page.Page == 1 {
+				fmt.Fprintf(out, "📓 Tag: %s\n", tag.TagName)
+			} else {
+				fmt.Fprintf(out, "📓 Tag: %s (page %d)\n", tag.TagName, page.Page)
+			}
+		}
+
+		if config.Feeds.PerTag {
+			if err := s.generateRSSFeed(s.tagFeed(tag), tagIndex[tag], out); err != nil {
+				return err
+			}
+			if err := s.generateAtomFeed(s.tagFeed(tag), tagIndex[tag], out); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Site) generateTagsIndexHTML(tmpl *template.Template, tagsIndexFilePath string, tagIndex map[Tag][]Post) error {
+	config := s.Config
+	labels := Labels{
+		Title: config.BlogName + " Tags",
+	}
+
+	unfurl := Unfurl{
+		Title:       config.BlogName,
+		URL:         s.links.Tags,
+		Description: config.BlogName + ": Tags",
+		SiteName:    config.BlogName,
+		Locale:      config.Locale,
+	}
+
+	data := map[string]interface{}{
+		"Config":    config,
+		"Labels":    labels,
+		"Tags":      tagIndex,
+		"Version":   s.Version,
+		"Now":       s.now,
+		"Canonical": s.links.Tags,
+		"Links":     s.links,
+		"Unfurl":    unfurl,
+		"Badges":    s.badges,
+	}
+
+	if err := s.execute(tmpl, tagsIndexFilePath, data); err != nil {
+		return err
+	}
+	s.logf("📓 Tag Index: %s\n", tagsIndexFilePath)
+	return nil
+}
+
+func (s *Site) generateCustomPages() error {
+	config := s.Config
+	for _, page := range config.Pages {
+		tmpl, templateKey, err := s.parseTemplate(filepath.Join(config.TemplatesDir, page.Template))
+		if err != nil {
+			return err
+		}
+		customPagePath := filepath.Join(config.OutputDir, page.Link, "index.html")
+		if s.unchanged(customPagePath, hashKey(s.siteKey, templateKey)) {
+			continue
+		}
+
+		labels := Labels{
+			Title: page.Title,
+		}
+
+		url := buildCustomPageLink(config, page)
+		unfurl := Unfurl{
+			Title:       config.BlogName,
+			URL:         url,
+			Description: page.Title,
+			SiteName:    config.BlogName,
+			Locale:      config.Locale,
+		}
+
+		data := map[string]interface{}{
+			"Config":    config,
+			"Labels":    labels,
+			"Version":   s.Version,
+			"Now":       s.now,
+			"Canonical": url,
+			"Links":     s.links,
+			"Unfurl":    unfurl,
+			"Badges":    s.badges,
+		}
+
+		if err := s.execute(tmpl, customPagePath, data); err != nil {
+			return err
+		}
+
+		s.logf("📘 Page: %s\n", page.Title)
+	}
+	return nil
+}
+
+func (s *Site) generateSearchHTML() error {
+	config := s.Config
+	tmpl, templateKey, err := s.parseTemplate(filepath.Join(config.TemplatesDir, "search.html"))
+	if err != nil {
+		return err
+	}
+	searchPath := filepath.Join(config.OutputDir, config.Search.Dir, "index.html")
+	if s.unchanged(searchPath, hashKey(s.siteKey, templateKey)) {
+		return nil
+	}
+	data := map[string]interface{}{
+		"Config": config,
+		"Links":  s.links,
+		"Badges": s.badges,
+		"Now":    s.now,
+	}
+	if err := s.execute(tmpl, searchPath, data); err != nil {
+		return err
+	}
+	s.logf("🔍 Search template written\n")
+	return nil
+}
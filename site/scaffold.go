@@ -0,0 +1,132 @@
+package site
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+ * Scaffolding for `draft new`: write a private post with valid front matter
+ * named YYYYMMDD.descriptive.title.md
+ */
+
+const defaultPostTemplate = "default.html"
+
+/*
+ * Create a new post in the input folder
+ *
+ * returns: path of the new file, err
+ */
+func (s *Site) NewPost(title string, tags []string, templateName string, now time.Time) (string, error) {
+	config := s.Config
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", errors.New("a title is required")
+	}
+	if templateName == "" {
+		templateName = defaultPostTemplate
+	}
+	if _, err := os.Stat(filepath.Join(config.TemplatesDir, templateName)); err != nil {
+		return "", &TemplateError{Template: templateName, Err: err}
+	}
+
+	taken, err := s.takenLinks()
+	if err != nil {
+		return "", err
+	}
+
+	/*
+	 * Derive a link from the title, adding -2, -3... until it is unique
+	 */
+	base := slugify(title)
+	if base == "" {
+		base = "post"
+	}
+	link := base
+	var filePath string
+	for n := 2; ; n++ {
+		filePath = filepath.Join(config.InputDir, fmt.Sprintf("%s.%s.md", now.Format("20060102"), strings.ReplaceAll(link, "-", ".")))
+		if _, err := os.Stat(filePath); !taken[link] && errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		link = fmt.Sprintf("%s-%d", base, n)
+	}
+	if err := validateLinkName(link); err != nil {
+		return "", err
+	}
+
+	var cleanTags []string
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			cleanTags = append(cleanTags, tag)
+		}
+	}
+
+	frontMatter := FrontMatter{
+		Title:       title,
+		Link:        link,
+		Description: title,
+		Tags:        cleanTags,
+		Published:   now.Format(time.RFC3339),
+		Template:    templateName,
+		Author:      config.Author,
+		Status:      string(Private),
+	}
+	if err := validateHeaders(frontMatter, filePath); err != nil {
+		return "", err
+	}
+
+	var header bytes.Buffer
+	encoder := yaml.NewEncoder(&header)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(frontMatter); err != nil {
+		return "", err
+	}
+	content := fmt.Sprintf("---\n%s---\n\n## %s\n", header.String(), title)
+
+	if err := os.MkdirAll(config.InputDir, 0755); err != nil {
+		return "", &WriteError{Path: config.InputDir, Err: err}
+	}
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		return "", &WriteError{Path: filePath, Err: err}
+	}
+	return filePath, nil
+}
+
+/*
+ * Links already used by posts, pages and generated folders
+ */
+func (s *Site) takenLinks() (map[string]bool, error) {
+	config := s.Config
+	taken := reservedLinks(config)
+	for _, page := range config.Pages {
+		taken[page.Link] = true
+	}
+
+	files, err := os.ReadDir(config.InputDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return taken, nil
+		}
+		return nil, fmt.Errorf("failed to read directory '%s': %w", config.InputDir, err)
+	}
+	for _, file := range files {
+		filePath := postSource(config.InputDir, file)
+		if filePath == "" {
+			continue
+		}
+		// Posts that don't parse can't claim a link; the build reports them
+		frontMatter, _, err := parseFileWithHeaders(filePath)
+		if err == nil && frontMatter.Link != "" {
+			taken[frontMatter.Link] = true
+		}
+	}
+	return taken, nil
+}
@@ -0,0 +1,88 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Hello World", "hello-world"},
+		{"  Hello,   World!  ", "hello-world"},
+		{"C/C++ & Go", "c-c-go"},
+		{"../etc/passwd", "etc-passwd"},
+		{"2025: A Year", "2025-a-year"},
+		{"🎉", ""},
+		{"Hello, World: Ünïcode", "hello-world-ünïcode"},
+		{"Привет, мир", "привет-мир"},
+		{"日本語のブログ", "日本語のブログ"},
+		{"Cafe\u0301 Crème", "cafe\u0301-crème"},
+		{"a" + strings.Repeat("é", 150), "a" + strings.Repeat("é", 99)},
+	}
+
+	for _, tt := range tests {
+		if got := slugify(tt.input); got != tt.expected {
+			t.Errorf("slugify(%q) = %q; expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestNewPost(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.hello.md": post("Hello", "hello-world", "2025-01-01T10:00:00Z", "public"),
+	})
+	s.Config.Pages = []Page{{Template: "default.html", Title: "About", Link: "about"}}
+	now := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+
+	tests := []struct {
+		title        string
+		expectedLink string
+		expectedFile string
+	}{
+		{"Hello: World", "hello-world-2", "20250314.hello.world.2.md"},
+		{"About", "about-2", "20250314.about.2.md"},
+		{"A Brand New Post", "a-brand-new-post", "20250314.a.brand.new.post.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			path, err := s.NewPost(tt.title, []string{"go", " meta "}, "", now)
+			if err != nil {
+				t.Fatalf("NewPost(%q) = %v", tt.title, err)
+			}
+			if filepath.Base(path) != tt.expectedFile {
+				t.Errorf("NewPost(%q) wrote %s; expected %s", tt.title, filepath.Base(path), tt.expectedFile)
+			}
+
+			frontMatter, _, err := parseFileWithHeaders(path)
+			if err != nil {
+				t.Fatalf("parseFileWithHeaders() = %v", err)
+			}
+			if err := validateHeaders(*frontMatter, path); err != nil {
+				t.Errorf("new post fails validation: %v", err)
+			}
+			if frontMatter.Link != tt.expectedLink {
+				t.Errorf("link = %q; expected %q", frontMatter.Link, tt.expectedLink)
+			}
+			if frontMatter.Title != tt.title || frontMatter.Status != "private" || frontMatter.Published != "2025-03-14T09:26:53Z" {
+				t.Errorf("unexpected front matter: %+v", frontMatter)
+			}
+			if strings.Join(frontMatter.Tags, ",") != "go,meta" {
+				t.Errorf("tags = %v; expected [go meta]", frontMatter.Tags)
+			}
+		})
+	}
+
+	if _, err := s.NewPost("Missing Template", nil, "nope.html", now); err == nil {
+		t.Errorf("NewPost() with a missing template should fail")
+	}
+	if _, err := os.Stat(filepath.Join(s.Config.InputDir, "20250314.missing.template.md")); !os.IsNotExist(err) {
+		t.Errorf("no file should be written when the template is missing")
+	}
+}
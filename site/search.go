@@ -0,0 +1,79 @@
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+/*
+ * Sluggo version 1 structures
+ */
+
+type Corpus struct {
+	Name      string     // Name of corpus
+	URL       string     // URL e.g. https://harrison.blog
+	Created   int64      // Create date in UNIX time since epoch
+	Version   int        // Version of JSON input file
+	Documents []Document // List of documents
+}
+
+type Document struct {
+	ID          string              // Document ID, typically a URL
+	Title       string              // Docuemnt title e.g. "Hello World"
+	Description string              // Brief description, first sentence of document or so
+	Text        string              // Entire document
+	Attributes  map[string][]string // Arbitrary attributes e.g. {"author": ["Harrison"]}
+	Hints       []string            // Hints provide best matches for search
+}
+
+func convertTagsToStrings(tags []Tag) []string {
+	tagStrings := make([]string, len(tags))
+	for i, tag := range tags {
+		tagStrings[i] = tag.TagName
+	}
+	return tagStrings
+}
+
+/*
+ * Optionally generate Sluggo (search engine) export
+ */
+
+func (s *Site) generateSluggoExport(posts []Post) error {
+	config := s.Config
+	if s.unchanged(config.Search.Path, hashKey(s.siteKey, "sluggo", postsKey(posts))) {
+		return nil
+	}
+	file, err := s.create(config.Search.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	payload := Corpus{
+		Name:    config.BlogName,
+		URL:     config.URL,
+		Created: time.Now().Unix(),
+		Version: 1,
+	}
+	for _, post := range posts {
+		doc := Document{
+			ID:          post.URL,
+			Title:       post.FrontMatter.Title,
+			Description: post.Summary,
+			Text:        post.Text,
+			Attributes:  map[string][]string{"author": {post.FrontMatter.Author}, "tags": convertTagsToStrings(post.Tags)},
+			Hints:       []string{},
+		}
+		payload.Documents = append(payload.Documents, doc)
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(payload); err != nil {
+		return &WriteError{Path: config.Search.Path, Err: fmt.Errorf("failed to encode sluggo export: %w", err)}
+	}
+
+	s.logf("📔 Sluggo export: %s\n", config.Search.Path)
+	return nil
+}
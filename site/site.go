@@ -0,0 +1,411 @@
+/*
+ * Package site converts a folder of Markdown posts and HTML templates into a
+ * static blog. The draft command is a thin wrapper around it.
+ */
+package site
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+ * Support for external search engines
+ */
+type SearchConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Engine  string `yaml:"engine"`
+	URL     string `yaml:"url"`
+	Path    string `yaml:"path"`
+	Dir     string `yaml:"dir"`
+}
+
+/*
+ * How `related` entries in front matter are resolved
+ */
+type RelatedConfig struct {
+	Strict        bool `yaml:"strict"`        // Fail the build on a missing or private related post
+	Bidirectional bool `yaml:"bidirectional"` // A => B also lists A on B
+}
+
+/*
+ * Fields in config.yaml
+ */
+type Config struct {
+	InputDir                string            `yaml:"input_dir"`
+	TemplatesDir            string            `yaml:"templates_dir"`
+	OutputDir               string            `yaml:"output_dir"`
+	BadgesDir               string            `yaml:"badges_dir"`
+	IndexTemplatePath       string            `yaml:"index_template_path"`
+	TagsIndexTemplatePath   string            `yaml:"tags_index_template_path"`
+	TagPageTemplatePath     string            `yaml:"tag_page_template_path"`
+	ArchiveTemplatePath     string            `yaml:"archive_template_path"`
+	ArchivePageTemplatePath string            `yaml:"archive_page_template_path"`
+	Author                  string            `yaml:"author"`
+	BlogName                string            `yaml:"blog_name"`
+	Description             string            `yaml:"description"`
+	Email                   string            `yaml:"email"`
+	Language                string            `yaml:"language"`
+	Locale                  string            `yaml:"locale"`
+	Lang                    string            `yaml:"lang"`
+	BackLabel               string            `yaml:"back_label"`
+	CSSFiles                []string          `yaml:"css_files"`
+	JSFiles                 []string          `yaml:"js_files"`
+	Pages                   []Page            `yaml:"pages"`
+	URL                     string            `yaml:"url"`
+	BasePath                string            `yaml:"base_path"`
+	Badges                  []Badge           `yaml:"badges"`
+	FediverseCreator        string            `yaml:"fediverse_creator"`
+	Search                  SearchConfig      `yaml:"search"`
+	Rights                  string            `yaml:"rights"`
+	CacheDir                string            `yaml:"cache_dir"`
+	Paginate                PaginateConfig    `yaml:"paginate"`
+	Feeds                   FeedsConfig       `yaml:"feeds"`
+	Related                 RelatedConfig     `yaml:"related"`
+	UpdatedFallback         string            `yaml:"updated_fallback"`
+	TagAliases              map[string]string `yaml:"tag_aliases"`
+	StaticDir               string            `yaml:"static_dir"`
+	Assets                  AssetsConfig      `yaml:"assets"`
+	Images                  ImagesConfig      `yaml:"images"`
+	SocialCards             SocialCardsConfig `yaml:"social_cards"`
+	Highlight               HighlightConfig   `yaml:"highlight"`
+	Reading                 ReadingConfig     `yaml:"reading"`
+}
+
+type Badge struct {
+	Title string
+	URL   string
+	Icon  string
+	ID    string
+}
+
+type Page struct {
+	Template string
+	Title    string
+	Link     string
+}
+
+/*
+ * A configured site. Build may be called repeatedly, e.g. by a preview
+ * server, but not concurrently.
+ */
+type Site struct {
+	Config  Config
+	Version string    // Draft version, shown in templates and feeds
+	Log     io.Writer // Progress output, defaults to os.Stdout
+	NoCache bool      // Ignore the build cache and rewrite every file
+	Jobs    int       // Pages rendered at once, defaults to the number of CPUs
+	Future  bool      // Render posts whose published date hasn't arrived yet
+	Drafts  bool      // Render posts with status draft, unlisted and with a banner
+
+	mu           sync.Mutex // Guards result and templates while workers render
+	badges       map[string]template.HTML
+	links        Links
+	now          string
+	result       *BuildResult
+	cache        *buildCache
+	tags         *tagSet                       // Tags seen so far this build
+	siteKey      string                        // Hash of config, version and badges
+	templates    map[string]*template.Template // Parsed templates by path
+	templateKeys map[string]string             // Hash of each parsed template's files
+	assets       map[string]Asset              // Files in static_dir by logical name
+	images       map[string]ResponsiveImage    // Processed images by source path
+	cards        *cardLayout                   // nil unless social cards are enabled
+}
+
+/*
+ * Summary of a successful build
+ */
+type BuildResult struct {
+	Posts     []Post        // Public posts, new to old
+	Unlisted  []Post        // Posts rendered but not listed: unlisted, and drafts with Drafts set
+	Skipped   []string      // Links of posts that were not rendered e.g. private or scheduled
+	Files     []string      // Every file written to the output folder
+	Unchanged []string      // Files left alone because their inputs did not change
+	Removed   []string      // Files from a previous build that are no longer produced
+	Duration  time.Duration // Wall clock time of the build
+}
+
+func LoadConfig(filename string) (*Config, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, &ConfigError{Path: filename, Err: fmt.Errorf("failed to open config file: %w", err)}
+	}
+	defer file.Close()
+
+	var config Config
+	decoder := yaml.NewDecoder(file)
+	if err := decoder.Decode(&config); err != nil {
+		return nil, &ConfigError{Path: filename, Err: fmt.Errorf("failed to decode config file: %w", err)}
+	}
+
+	return &config, nil
+}
+
+/*
+ * Check that the fields every build depends on are present
+ */
+func Load(config Config) (*Site, error) {
+	var missing []string
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"input_dir", config.InputDir},
+		{"templates_dir", config.TemplatesDir},
+		{"output_dir", config.OutputDir},
+		{"badges_dir", config.BadgesDir},
+		{"index_template_path", config.IndexTemplatePath},
+		{"tags_index_template_path", config.TagsIndexTemplatePath},
+		{"tag_page_template_path", config.TagPageTemplatePath},
+	}
+	for _, field := range required {
+		if field.value == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &ConfigError{Err: fmt.Errorf("missing required fields: %s", strings.Join(missing, ", "))}
+	}
+	if config.Search.Enabled && config.Search.Path == "" {
+		return nil, &ConfigError{Err: errors.New("search is enabled but search.path is not set")}
+	}
+	switch config.UpdatedFallback {
+	case "", UpdatedFallbackGit, UpdatedFallbackMtime:
+	default:
+		return nil, &ConfigError{Err: fmt.Errorf("updated_fallback must be %s or %s, not %s", UpdatedFallbackGit, UpdatedFallbackMtime, config.UpdatedFallback)}
+	}
+	if config.Feeds.Limit < 0 || config.Feeds.TTL < 0 {
+		return nil, &ConfigError{Err: errors.New("feeds.limit and feeds.ttl must not be negative")}
+	}
+	if config.Paginate.Size < 0 {
+		return nil, &ConfigError{Err: errors.New("paginate.size must not be negative")}
+	}
+	if err := validateLinkName(paginatePath(config)); err != nil {
+		return nil, &ConfigError{Err: fmt.Errorf("paginate.path: %w", err)}
+	}
+	if err := validateImagesConfig(config.Images); err != nil {
+		return nil, &ConfigError{Err: err}
+	}
+	if config.Reading.WordsPerMinute < 0 || config.Reading.ExcerptWords < 0 || config.Reading.ShortDescription < 0 {
+		return nil, &ConfigError{Err: errors.New("reading.words_per_minute, reading.excerpt_words and reading.short_description must not be negative")}
+	}
+	if config.Highlight.Enabled {
+		if _, err := highlightStyle(config.Highlight.Style); err != nil {
+			return nil, &ConfigError{Err: fmt.Errorf("highlight.style: %w", err)}
+		}
+	}
+
+	return &Site{
+		Config: config,
+		Log:    os.Stdout,
+	}, nil
+}
+
+func (s *Site) logf(format string, args ...interface{}) {
+	fmt.Fprintf(s.Log, format, args...)
+}
+
+/*
+ * Render every public post, the index, tag pages, feeds, custom pages and
+ * sitemap into the output folder
+ */
+func (s *Site) Build(ctx context.Context) (_ *BuildResult, err error) {
+	start := time.Now()
+	config := s.Config
+	s.result = &BuildResult{}
+
+	/*
+	 * Load badges into map: filename => SVG
+	 */
+	badges, err := loadBadges(config.BadgesDir)
+	if err != nil {
+		return nil, err
+	}
+	s.badges = badges
+
+	key, err := siteKey(config, s.Version, badges)
+	if err != nil {
+		return nil, &ConfigError{Err: err}
+	}
+	if s.assets, err = s.loadStatic(); err != nil {
+		return nil, err
+	}
+	s.siteKey = hashKey(key, assetsKey(s.assets))
+	s.cache = loadCache(config.CacheDir, config.OutputDir, s.NoCache)
+	defer func() {
+		if err != nil {
+			s.cache.saveFailed()
+		}
+	}()
+	s.templates = make(map[string]*template.Template)
+	s.templateKeys = make(map[string]string)
+	s.images = make(map[string]ResponsiveImage)
+	s.cards = nil
+	if config.SocialCards.Enabled {
+		if s.cards, err = loadCardLayout(config.SocialCards); err != nil {
+			return nil, &ConfigError{Err: err}
+		}
+	}
+	if s.tags, err = newTagSet(config); err != nil {
+		return nil, err
+	}
+
+	/*
+	 * Fetch a list of all posts
+	 */
+	files, err := os.ReadDir(config.InputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory '%s': %w", config.InputDir, err)
+	}
+
+	/*
+	 * Create output folder
+	 */
+	if err := os.MkdirAll(config.OutputDir, 0755); err != nil {
+		return nil, &WriteError{Path: config.OutputDir, Err: err}
+	}
+
+	/*
+	 * Create tags folder
+	 */
+	tagsOutputDir := filepath.Join(config.OutputDir, "tags")
+	if err := os.MkdirAll(tagsOutputDir, 0755); err != nil {
+		return nil, &WriteError{Path: tagsOutputDir, Err: err}
+	}
+
+	/*
+	 * Create search folder
+	 */
+	if config.Search.Enabled {
+		searchDir := filepath.Join(config.OutputDir, config.Search.Dir)
+		if err := os.MkdirAll(searchDir, 0755); err != nil {
+			return nil, &WriteError{Path: searchDir, Err: err}
+		}
+	}
+
+	/*
+	 * Timestamp
+	 */
+	s.now = time.Now().Format("January 2, 2006 at 3:04 PM")
+
+	s.links = Links{
+		Home:     buildRootLink(config),
+		Atom:     buildAtomLink(config),
+		RSS:      buildRSSLink(config),
+		JSONFeed: buildJSONFeedLink(config),
+		Tags:     buildTagsLink(config),
+		Sitemap:  buildSitemapLink(config),
+		Rights:   buildRightsLink(config),
+	}
+	if archiveEnabled(config) {
+		s.links.Archive = buildArchiveLink(config, 0, 0)
+	}
+
+	posts, unlisted, postIndex, tagIndex, err := s.loadPosts(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.renderPosts(ctx, posts, unlisted, postIndex); err != nil {
+		return nil, err
+	}
+
+	if err := s.generateIndexHTML(posts); err != nil {
+		return nil, err
+	}
+	if err := s.generateTagsHTML(ctx, tagsOutputDir, tagIndex); err != nil {
+		return nil, err
+	}
+	var archive []ArchivePeriod
+	if archiveEnabled(config) {
+		archive = buildArchive(config, posts)
+		if err := s.generateArchiveHTML(ctx, archive, posts); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.generateRSSFeed(s.siteFeed(), posts, s.Log); err != nil {
+		return nil, err
+	}
+	if err := s.generateAtomFeed(s.siteFeed(), posts, s.Log); err != nil {
+		return nil, err
+	}
+	if err := s.generateJSONFeed(posts); err != nil {
+		return nil, err
+	}
+	if err := s.generateCustomPages(); err != nil {
+		return nil, err
+	}
+	if err := s.generateSitemap(posts, tagIndex, archive); err != nil {
+		return nil, err
+	}
+	if config.Search.Enabled {
+		if err := s.generateSluggoExport(posts); err != nil {
+			return nil, err
+		}
+		if err := s.generateSearchHTML(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.writeStatic(); err != nil {
+		return nil, err
+	}
+
+	s.result.Removed = s.cache.prune()
+	for _, path := range s.result.Removed {
+		s.logf("🗑️  Removed: %s\n", path)
+	}
+	if err := s.cache.save(); err != nil {
+		return nil, err
+	}
+	if len(s.result.Unchanged) > 0 {
+		s.logf("♻️  Unchanged: %d file(s) skipped\n", len(s.result.Unchanged))
+	}
+
+	sort.Strings(s.result.Files)
+	sort.Strings(s.result.Unchanged)
+	s.result.Posts = posts
+	s.result.Unlisted = unlisted
+	s.result.Duration = time.Since(start)
+	return s.result, nil
+}
+
+/*
+ * Create an output file and remember it in the build result
+ */
+func (s *Site) create(path string) (*os.File, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, &WriteError{Path: path, Err: err}
+	}
+	s.mu.Lock()
+	s.result.Files = append(s.result.Files, path)
+	s.mu.Unlock()
+	return file, nil
+}
+
+/*
+ * Report whether an output can be left alone because the inputs behind it
+ * (summarised by key) are the same as last build
+ */
+func (s *Site) unchanged(outputPath, key string) bool {
+	if s.cache.unchanged(outputPath, key) {
+		s.mu.Lock()
+		s.result.Unchanged = append(s.result.Unchanged, outputPath)
+		s.mu.Unlock()
+		return true
+	}
+	return false
+}
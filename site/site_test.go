@@ -0,0 +1,415 @@
+package site
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPost = `---
+title: %s
+link: %s
+description: Example description
+tags:
+  - meta
+published: %s
+template: default.html
+author: harrison
+status: %s
+---
+
+## Hello World
+
+Welcome to my blog.
+`
+
+/*
+ * Lay out a minimal site in a temporary folder
+ */
+func newTestSite(t *testing.T, posts map[string]string) *Site {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range []string{"posts", "templates", "badges"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := map[string]string{
+		"templates/shared.html":  `{{define "header"}}<head><title>{{ .Labels.Title }}</title></head>{{end}}{{define "footer"}}<footer></footer>{{end}}`,
+		"templates/default.html": `<html>{{ template "header" . }}<body>{{ .Content }}{{ range .Post.Related }}<a href="{{ .URL }}">{{ .FrontMatter.Title }}</a>{{ end }}</body></html>`,
+		"templates/index.html":   `<html>{{ template "header" . }}<body>{{ range .Posts }}<a href="{{ .URL }}">{{ .FrontMatter.Title }}</a>{{ end }}</body></html>`,
+		"templates/tags.html":    `<html>{{ template "header" . }}<body>{{ range $tag, $posts := .Tags }}{{ $tag.TagName }}{{ end }}</body></html>`,
+		"templates/tag.html":     `<html>{{ template "header" . }}<body>{{ .Key }}</body></html>`,
+		"badges/home.svg":        `<svg></svg>`,
+	}
+	for name, content := range posts {
+		files[filepath.Join("posts", name)] = content
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := Load(Config{
+		InputDir:              filepath.Join(root, "posts"),
+		TemplatesDir:          filepath.Join(root, "templates"),
+		OutputDir:             filepath.Join(root, "output"),
+		CacheDir:              filepath.Join(root, "cache"),
+		BadgesDir:             filepath.Join(root, "badges"),
+		IndexTemplatePath:     filepath.Join(root, "templates", "index.html"),
+		TagsIndexTemplatePath: filepath.Join(root, "templates", "tags.html"),
+		TagPageTemplatePath:   filepath.Join(root, "templates", "tag.html"),
+		BlogName:              "Test Blog",
+		URL:                   "https://example.com",
+	})
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	s.Log = io.Discard
+	return s
+}
+
+func post(title, link, published, status string) string {
+	return fmt.Sprintf(testPost, title, link, published, status)
+}
+
+func TestBuild(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md":  post("First", "first", "2025-01-01T10:00:00Z", "public"),
+		"20250102.second.md": post("Second", "second", "2025-01-02T10:00:00Z", "public"),
+		"20250103.hidden.md": post("Hidden", "hidden", "2025-01-03T10:00:00Z", "private"),
+	})
+
+	result, err := s.Build(context.Background())
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	if len(result.Posts) != 2 || result.Posts[0].FrontMatter.Link != "second" {
+		t.Errorf("expected 2 posts, newest first; got %d", len(result.Posts))
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != "hidden" {
+		t.Errorf("expected private post to be skipped, got %v", result.Skipped)
+	}
+	for _, name := range []string{"index.html", "first/index.html", "tags/meta/index.html", "rss.xml", "atom.xml", "sitemap.xml"} {
+		if _, err := os.Stat(filepath.Join(s.Config.OutputDir, name)); err != nil {
+			t.Errorf("expected %s to be written: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(s.Config.OutputDir, "hidden")); !os.IsNotExist(err) {
+		t.Errorf("private post should not be written")
+	}
+}
+
+func TestScheduledPosts(t *testing.T) {
+	for _, future := range []bool{false, true} {
+		s := newTestSite(t, map[string]string{
+			"20250101.first.md": post("First", "first", "2025-01-01T10:00:00Z", "public"),
+			"29990101.later.md": post("Later", "later", "2999-01-01T10:00:00Z", "public"),
+		})
+		s.Future = future
+
+		result, err := s.Build(context.Background())
+		if err != nil {
+			t.Fatalf("Build() = %v", err)
+		}
+
+		expectedPosts, expectedSkipped := 1, 1
+		if future {
+			expectedPosts, expectedSkipped = 2, 0
+		}
+		if len(result.Posts) != expectedPosts || len(result.Skipped) != expectedSkipped {
+			t.Errorf("future=%v: expected %d posts and %d skipped, got %d and %v", future, expectedPosts, expectedSkipped, len(result.Posts), result.Skipped)
+		}
+		_, err = os.Stat(filepath.Join(s.Config.OutputDir, "later", "index.html"))
+		if future == os.IsNotExist(err) {
+			t.Errorf("future=%v: unexpected scheduled post output: %v", future, err)
+		}
+		for _, name := range []string{"rss.xml", "sitemap.xml", "tags/meta/index.html"} {
+			content, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, name))
+			if !future && strings.Contains(string(content), "later") {
+				t.Errorf("%s: scheduled post should be left out", name)
+			}
+		}
+	}
+}
+
+func TestUnlistedAndDraftPosts(t *testing.T) {
+	for _, drafts := range []bool{false, true} {
+		s := newTestSite(t, map[string]string{
+			"20250101.first.md":  post("First", "first", "2025-01-01T10:00:00Z", "public"),
+			"20250102.hidden.md": post("Hidden", "hidden", "2025-01-02T10:00:00Z", "unlisted"),
+			"20250103.wip.md":    post("Work In Progress", "wip", "2025-01-03T10:00:00Z", "draft"),
+			"20250104.second.md": post("Second", "second", "2025-01-04T10:00:00Z", "public"),
+		})
+		s.Drafts = drafts
+		defaultTemplate := `<html><body>{{ .Banner }}{{ range .Post.Previous }}prev:{{ .FrontMatter.Link }}{{ end }}{{ range .Post.Next }}next:{{ .FrontMatter.Link }}{{ end }}</body></html>`
+		if err := os.WriteFile(filepath.Join(s.Config.TemplatesDir, "default.html"), []byte(defaultTemplate), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := s.Build(context.Background())
+		if err != nil {
+			t.Fatalf("Build() = %v", err)
+		}
+
+		if len(result.Posts) != 2 {
+			t.Errorf("drafts=%v: expected 2 listed posts, got %d", drafts, len(result.Posts))
+		}
+		expectedUnlisted := 1
+		if drafts {
+			expectedUnlisted = 2
+		}
+		if len(result.Unlisted) != expectedUnlisted {
+			t.Errorf("drafts=%v: expected %d unlisted posts, got %d", drafts, expectedUnlisted, len(result.Unlisted))
+		}
+
+		// Unlisted posts are reachable by URL but have no neighbours
+		hidden, err := os.ReadFile(filepath.Join(s.Config.OutputDir, "hidden", "index.html"))
+		if err != nil {
+			t.Fatalf("drafts=%v: unlisted post not rendered: %v", drafts, err)
+		}
+		if strings.Contains(string(hidden), "prev:") || strings.Contains(string(hidden), "next:") || strings.Contains(string(hidden), draftBanner) {
+			t.Errorf("drafts=%v: unexpected unlisted post content: %s", drafts, hidden)
+		}
+
+		// ...and the public posts link straight past them
+		first, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "first", "index.html"))
+		if !strings.Contains(string(first), "next:second") {
+			t.Errorf("drafts=%v: expected first to link to second, got %s", drafts, first)
+		}
+
+		wip, err := os.ReadFile(filepath.Join(s.Config.OutputDir, "wip", "index.html"))
+		if drafts && (err != nil || !strings.Contains(string(wip), draftBanner)) {
+			t.Errorf("draft post should be rendered with a banner: %v %s", err, wip)
+		}
+		if !drafts && !os.IsNotExist(err) {
+			t.Errorf("draft post should not be rendered without --drafts")
+		}
+
+		for _, name := range []string{"index.html", "rss.xml", "feed.json", "sitemap.xml", "tags/meta/index.html"} {
+			content, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, name))
+			if strings.Contains(string(content), "hidden") || strings.Contains(string(content), "wip") {
+				t.Errorf("drafts=%v: %s should not list unlisted posts", drafts, name)
+			}
+		}
+	}
+}
+
+func TestIncrementalBuild(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md":  post("First", "first", "2025-01-01T10:00:00Z", "public"),
+		"20250102.second.md": post("Second", "second", "2025-01-02T10:00:00Z", "public"),
+		"20250103.third.md":  post("Third", "third", "2025-01-03T10:00:00Z", "public"),
+	})
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	result, err := s.Build(context.Background())
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if len(result.Files) != 0 {
+		t.Errorf("expected nothing to be rewritten, got %v", result.Files)
+	}
+
+	// Editing the oldest post touches it, its neighbor and the listings
+	first := filepath.Join(s.Config.InputDir, "20250101.first.md")
+	if err := os.WriteFile(first, []byte(post("First Edited", "first", "2025-01-01T10:00:00Z", "public")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = s.Build(context.Background())
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	written := make(map[string]bool)
+	for _, path := range result.Files {
+		rel, _ := filepath.Rel(s.Config.OutputDir, path)
+		written[rel] = true
+	}
+	for _, name := range []string{"first/index.html", "second/index.html", "index.html", "rss.xml"} {
+		if !written[name] {
+			t.Errorf("expected %s to be rewritten", name)
+		}
+	}
+	if written["third/index.html"] {
+		t.Errorf("third/index.html should have been left alone")
+	}
+
+	// Removing a post removes its page
+	if err := os.Remove(first); err != nil {
+		t.Fatal(err)
+	}
+	result, err = s.Build(context.Background())
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Config.OutputDir, "first")); !os.IsNotExist(err) {
+		t.Errorf("expected the removed post's folder to be deleted, got %v", result.Removed)
+	}
+}
+
+func TestUpgradeRendersAgain(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md": post("First", "first", "2025-01-01T10:00:00Z", "public"),
+	})
+	s.Version = "1.0"
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	// Stand-in for HTML rendered by the old version's renderer
+	manifestPath := filepath.Join(s.Config.CacheDir, "manifest.json")
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest cacheManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	for hash, entry := range manifest.Posts {
+		entry.HTML = "stale"
+		manifest.Posts[hash] = entry
+	}
+	if content, err = json.Marshal(manifest); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(manifestPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Version = "2.0"
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	page, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "first", "index.html"))
+	if strings.Contains(string(page), "stale") || !strings.Contains(string(page), "Welcome to my blog.") {
+		t.Errorf("expected the post to be rendered again after an upgrade, got %s", page)
+	}
+}
+
+func TestFailedBuildForgetsRewrittenPages(t *testing.T) {
+	original := post("Hello", "hello", "2025-01-01T10:00:00Z", "public")
+	s := newTestSite(t, map[string]string{"20250101.hello.md": original})
+	s.Jobs = 1
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	// The edited post is written before the broken one fails the build
+	helloPath := filepath.Join(s.Config.InputDir, "20250101.hello.md")
+	brokenPath := filepath.Join(s.Config.InputDir, "20240101.broken.md")
+	edited := strings.Replace(original, "Welcome to my blog.", "Edited.", 1)
+	broken := strings.Replace(post("Broken", "broken", "2024-01-01T10:00:00Z", "public"), "default.html", "missing.html", 1)
+	if err := os.WriteFile(helloPath, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(brokenPath, []byte(broken), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Build(context.Background()); err == nil {
+		t.Fatal("expected the build to fail on a missing template")
+	}
+
+	// Reverting both changes brings back the old inputs of hello/
+	if err := os.WriteFile(helloPath, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(brokenPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	page, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "hello", "index.html"))
+	if !strings.Contains(string(page), "Welcome to my blog.") {
+		t.Errorf("expected hello/ to be written again after the failed build, got %s", page)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		posts map[string]string
+		pages []Page
+		check func(error) bool
+	}{
+		{
+			name: "Duplicate link",
+			posts: map[string]string{
+				"20250101.a.md": post("A", "same", "2025-01-01T10:00:00Z", "public"),
+				"20250102.b.md": post("B", "same", "2025-01-02T10:00:00Z", "public"),
+			},
+			check: func(err error) bool {
+				var target *DuplicateLinkError
+				return errors.As(err, &target) && target.Link == "same"
+			},
+		},
+		{
+			name: "Duplicate page link",
+			posts: map[string]string{
+				"20250101.a.md": post("A", "a", "2025-01-01T10:00:00Z", "public"),
+			},
+			pages: []Page{
+				{Template: "index.html", Title: "About", Link: "about"},
+				{Template: "index.html", Title: "About Me", Link: "about"},
+			},
+			check: func(err error) bool {
+				var target *DuplicateLinkError
+				return errors.As(err, &target) && target.Link == "about"
+			},
+		},
+		{
+			name: "Invalid status",
+			posts: map[string]string{
+				"20250101.a.md": post("A", "a", "2025-01-01T10:00:00Z", "bogus"),
+			},
+			check: func(err error) bool {
+				var target *ValidationError
+				return errors.As(err, &target) && len(target.Problems) == 1
+			},
+		},
+		{
+			name: "Bad date",
+			posts: map[string]string{
+				"20250101.a.md": post("A", "a", "yesterday", "public"),
+			},
+			check: func(err error) bool {
+				var target *PostError
+				return errors.As(err, &target)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSite(t, tt.posts)
+			s.Config.Pages = tt.pages
+			_, err := s.Build(context.Background())
+			if err == nil {
+				t.Fatalf("Build() = nil; expected an error")
+			}
+			if !tt.check(err) {
+				t.Errorf("Build() returned unexpected error type %T: %v", err, err)
+			}
+		})
+	}
+}
+
+func TestLoadMissingFields(t *testing.T) {
+	_, err := Load(Config{InputDir: "posts"})
+	var target *ConfigError
+	if !errors.As(err, &target) {
+		t.Fatalf("Load() = %v; expected a ConfigError", err)
+	}
+}
@@ -0,0 +1,146 @@
+package site
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+type URLSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URLs    []URL    `xml:"url"`
+}
+
+type URL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+/*
+ * Sitemap
+ */
+
+func (s *Site) generateSitemap(posts []Post, tagIndex map[Tag][]Post, archive []ArchivePeriod) error {
+	config := s.Config
+	var urls []URL
+
+	// Home page
+	urls = append(urls, URL{
+		Loc:        buildRootLink(config),
+		LastMod:    time.Now().Format("2006-01-02"),
+		ChangeFreq: "daily",
+		Priority:   "1.0",
+	})
+
+	// Later pages of the home page
+	for _, page := range paginate(config, posts, buildRootLink(config))[1:] {
+		urls = append(urls, URL{
+			Loc:        page.URL,
+			LastMod:    time.Now().Format("2006-01-02"),
+			ChangeFreq: "daily",
+			Priority:   "0.5",
+		})
+	}
+
+	// Tags page
+	urls = append(urls, URL{
+		Loc:        buildTagsLink(config),
+		LastMod:    time.Now().Format("2006-01-02"),
+		ChangeFreq: "weekly",
+		Priority:   "0.8",
+	})
+
+	// Every page of every tag, in name order
+	tags := make([]Tag, 0, len(tagIndex))
+	for tag := range tagIndex {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].TagName < tags[j].TagName
+	})
+	for _, tag := range tags {
+		for _, page := range paginate(config, tagIndex[tag], tag.URL) {
+			urls = append(urls, URL{
+				Loc:        page.URL,
+				LastMod:    lastUpdated(page.Posts).Format(time.RFC3339),
+				ChangeFreq: "weekly",
+				Priority:   "0.5",
+			})
+		}
+	}
+
+	// RSS page
+	urls = append(urls, URL{
+		Loc:      buildRSSLink(config),
+		LastMod:  time.Now().Format("2006-01-02"),
+		Priority: "0.7",
+	})
+
+	// Static pages from Config.Pages
+	for _, page := range config.Pages {
+		urls = append(urls, URL{
+			Loc:        buildCustomPageLink(config, page),
+			LastMod:    time.Now().Format("2006-01-02"),
+			ChangeFreq: "monthly",
+			Priority:   "0.5",
+		})
+	}
+
+	// Archive pages, last modified when their newest post was published
+	if len(archive) > 0 {
+		urls = append(urls, URL{
+			Loc:        s.links.Archive,
+			LastMod:    archive[0].Posts[0].PubTime.Format(time.RFC3339),
+			ChangeFreq: "weekly",
+			Priority:   "0.6",
+		})
+	}
+	for _, period := range archivePeriods(archive) {
+		urls = append(urls, URL{
+			Loc:        period.URL,
+			LastMod:    period.Posts[0].PubTime.Format(time.RFC3339),
+			ChangeFreq: "monthly",
+			Priority:   "0.4",
+		})
+	}
+
+	// Posts
+	for _, post := range posts {
+		urls = append(urls, URL{
+			Loc:        buildPostLink(config, post.FrontMatter.Link),
+			LastMod:    post.UpdatedTime.Format(time.RFC3339), // ISO 8601
+			ChangeFreq: "weekly",
+			Priority:   "0.9",
+		})
+	}
+
+	sitemap := URLSet{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		URLs:  urls,
+	}
+
+	outputFilePath := filepath.Join(config.OutputDir, "sitemap.xml")
+	if s.unchanged(outputFilePath, hashKey(s.siteKey, "sitemap", postsKey(posts))) {
+		return nil
+	}
+	file, err := s.create(outputFilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	file.WriteString(xml.Header)
+	if err := encoder.Encode(sitemap); err != nil {
+		return &WriteError{Path: outputFilePath, Err: fmt.Errorf("failed to encode sitemap: %w", err)}
+	}
+
+	s.logf("📔 Sitemap %s\n", outputFilePath)
+	return nil
+}
@@ -0,0 +1,184 @@
+package site
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+/*
+ * Static files: everything in static_dir is mirrored into output_dir.
+ * CSS and JS can be minified and fingerprinted with a hash of their
+ * content, e.g. css/style.css => css/style.3f2a1c9e.css, so they can be
+ * cached forever. Templates find the final URL with `asset`.
+ */
+
+type AssetsConfig struct {
+	Minify      bool `yaml:"minify"`      // Minify CSS and JS
+	Fingerprint bool `yaml:"fingerprint"` // Add a content hash to CSS and JS file names
+}
+
+/*
+ * A file in static_dir, keyed by its logical name: the path relative to
+ * static_dir with forward slashes, e.g. css/style.css
+ */
+type Asset struct {
+	URL       string // Where the file is published, fingerprinted if enabled
+	Integrity string // Subresource integrity hash of CSS and JS, e.g. sha384-...
+	source    string
+	output    string // Path relative to output_dir
+	content   []byte // Processed CSS and JS, nil for files copied as is
+	key       string // Cache key of the output
+}
+
+/*
+ * Walk static_dir, minifying and hashing CSS and JS along the way. Nothing
+ * is written until writeStatic so generated pages take precedence.
+ */
+func (s *Site) loadStatic() (map[string]Asset, error) {
+	config := s.Config
+	assets := make(map[string]Asset)
+	if config.StaticDir == "" {
+		return assets, nil
+	}
+
+	err := filepath.WalkDir(config.StaticDir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read static file '%s': %w", filePath, err)
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(config.StaticDir, filePath)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		asset := Asset{source: filePath, output: name}
+
+		ext := path.Ext(name)
+		if ext != ".css" && ext != ".js" {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			asset.key = hashKey(filePath, fmt.Sprint(info.Size()), info.ModTime().String())
+			asset.URL = buildStaticLink(config, name)
+			assets[name] = asset
+			return nil
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read static file '%s': %w", filePath, err)
+		}
+		if config.Assets.Minify && ext == ".css" {
+			content = []byte(minifyCSS(string(content)))
+		} else if config.Assets.Minify && ext == ".js" {
+			if content, err = minifyJS(content); err != nil {
+				return fmt.Errorf("failed to minify static file '%s': %w", filePath, err)
+			}
+		}
+		if config.Assets.Fingerprint {
+			sum := sha256.Sum256(content)
+			asset.output = strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:4]) + ext
+		}
+		integrity := sha512.Sum384(content)
+		asset.Integrity = "sha384-" + base64.StdEncoding.EncodeToString(integrity[:])
+		asset.content = content
+		asset.key = hashKey(string(content))
+		asset.URL = buildStaticLink(config, asset.output)
+		assets[name] = asset
+		return nil
+	})
+	if err != nil {
+		return nil, &ConfigError{Err: fmt.Errorf("static_dir: %w", err)}
+	}
+	return assets, nil
+}
+
+/*
+ * Combined hash of every asset URL, so pages are rewritten when a
+ * fingerprint changes
+ */
+func assetsKey(assets map[string]Asset) string {
+	var parts []string
+	for _, name := range slices.Sorted(maps.Keys(assets)) {
+		parts = append(parts, name, assets[name].URL, assets[name].Integrity)
+	}
+	return hashKey(parts...)
+}
+
+/*
+ * Copy static files into output_dir, skipping any a page was written to and
+ * images that were published without their metadata, see images.go
+ */
+func (s *Site) writeStatic() error {
+	for _, name := range slices.Sorted(maps.Keys(s.assets)) {
+		asset := s.assets[name]
+		outputPath := filepath.Join(s.Config.OutputDir, filepath.FromSlash(asset.output))
+		if _, ok := s.images[asset.source]; ok {
+			continue
+		}
+		if s.cache.produced(outputPath) {
+			s.logf("⚠️  Static: %s clashes with a generated file, skipping...\n", name)
+			continue
+		}
+		if s.unchanged(outputPath, asset.key) {
+			continue
+		}
+		if asset.content == nil {
+			if err := s.copyFile(asset.source, outputPath); err != nil {
+				return err
+			}
+		} else if err := s.writeFile(outputPath, asset.content); err != nil {
+			return err
+		}
+		s.logf("🧷 Static: %s\n", asset.output)
+	}
+	return nil
+}
+
+func (s *Site) writeFile(outputPath string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return &WriteError{Path: filepath.Dir(outputPath), Err: err}
+	}
+	file, err := s.create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.Write(content); err != nil {
+		return &WriteError{Path: outputPath, Err: err}
+	}
+	return nil
+}
+
+/*
+ * Template helper: URL of a file in static_dir by its logical name. Names
+ * that aren't static files, e.g. https://cdn.example.com/x.css, are
+ * returned unchanged so css_files and js_files may mix both.
+ */
+func (s *Site) assetURL(name string) string {
+	if asset, ok := s.assets[strings.TrimPrefix(name, "/")]; ok {
+		return asset.URL
+	}
+	return name
+}
+
+/*
+ * Template helper: subresource integrity hash of a CSS or JS file in
+ * static_dir, empty for anything else
+ */
+func (s *Site) assetIntegrity(name string) string {
+	return s.assets[strings.TrimPrefix(name, "/")].Integrity
+}
@@ -0,0 +1,90 @@
+package site
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestStaticAssets(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md": post("First", "first", "2025-01-01T10:00:00Z", "public"),
+	})
+	root := filepath.Dir(s.Config.InputDir)
+	s.Config.StaticDir = filepath.Join(root, "static")
+	s.Config.Assets = AssetsConfig{Minify: true, Fingerprint: true}
+	s.Config.CSSFiles = []string{"/css/style.css", "https://cdn.example.com/framework.css"}
+
+	files := map[string]string{
+		"static/css/style.css":    "body {\n  color: red;\n}\n",
+		"static/images/logo.png":  "png",
+		"static/js/app.js":        "const help = `\n    // not a comment\n`;\n",
+		"static/index.html":       "clashes with the home page",
+		"templates/shared.html":   `{{define "header"}}<head>{{ range .Config.CSSFiles }}<link href="{{ asset . }}" integrity="{{ integrity . }}">{{ end }}</head>{{end}}{{define "footer"}}{{end}}`,
+		"templates/fallback.html": `{{ asset "images/logo.png" }}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	css := s.assets["css/style.css"]
+	if !regexp.MustCompile(`^https://example.com/css/style\.[0-9a-f]{8}\.css$`).MatchString(css.URL) {
+		t.Errorf("expected a fingerprinted URL, got %s", css.URL)
+	}
+	if !strings.HasPrefix(css.Integrity, "sha384-") {
+		t.Errorf("expected an integrity hash, got %s", css.Integrity)
+	}
+	content, err := os.ReadFile(filepath.Join(s.Config.OutputDir, "css", filepath.Base(css.URL)))
+	if err != nil || string(content) != "body{color:red}" {
+		t.Errorf("expected minified CSS at the fingerprinted path, got %q, %v", content, err)
+	}
+	js := s.assets["js/app.js"]
+	if content, err := os.ReadFile(filepath.Join(s.Config.OutputDir, "js", filepath.Base(js.URL))); err != nil || string(content) != "const help=`\n    // not a comment\n`" {
+		t.Errorf("expected minified JS at the fingerprinted path, got %q, %v", content, err)
+	}
+	if logo, err := os.ReadFile(filepath.Join(s.Config.OutputDir, "images", "logo.png")); err != nil || string(logo) != "png" {
+		t.Errorf("expected other files to be copied as is, got %q, %v", logo, err)
+	}
+
+	index, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "index.html"))
+	if strings.Contains(string(index), "clashes") {
+		t.Errorf("generated pages should win over static files")
+	}
+	// html/template escapes + in attributes, which browsers undo
+	integrity := strings.ReplaceAll(css.Integrity, "+", "&#43;")
+	expected := `<link href="` + css.URL + `" integrity="` + integrity + `"><link href="https://cdn.example.com/framework.css" integrity="">`
+	if !strings.Contains(string(index), expected) {
+		t.Errorf("expected %s in %s", expected, index)
+	}
+
+	// Changing the stylesheet changes its name and every page linking to it
+	if err := os.WriteFile(filepath.Join(s.Config.StaticDir, "css", "style.css"), []byte("body { color: blue }"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if s.assets["css/style.css"].URL == css.URL {
+		t.Errorf("expected a new fingerprint")
+	}
+	index, _ = os.ReadFile(filepath.Join(s.Config.OutputDir, "index.html"))
+	if !strings.Contains(string(index), s.assets["css/style.css"].URL) {
+		t.Errorf("expected the home page to link to the new stylesheet")
+	}
+	if _, err := os.Stat(filepath.Join(s.Config.OutputDir, "css", filepath.Base(css.URL))); !os.IsNotExist(err) {
+		t.Errorf("expected the old stylesheet to be removed")
+	}
+}
@@ -0,0 +1,89 @@
+package site
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * Tags as written in front matter are folded into one Tag per slug, so
+ * "Go", "go" and (via tag_aliases) "golang" share a single page. The slug
+ * is the folder name under /tags/ and follows the same rules as links.
+ */
+
+type tagSet struct {
+	config  Config
+	aliases map[string]string // Slug of an alias => canonical tag name
+	bySlug  map[string]Tag
+}
+
+func newTagSet(config Config) (*tagSet, error) {
+	t := &tagSet{
+		config:  config,
+		aliases: make(map[string]string),
+		bySlug:  make(map[string]Tag),
+	}
+	for alias, name := range config.TagAliases {
+		aliasSlug, err := tagSlug(alias)
+		if err != nil {
+			return nil, &ConfigError{Err: fmt.Errorf("tag_aliases: %w", err)}
+		}
+		slug, err := tagSlug(name)
+		if err != nil {
+			return nil, &ConfigError{Err: fmt.Errorf("tag_aliases: %w", err)}
+		}
+		t.aliases[aliasSlug] = name
+		// The spelling in tag_aliases wins over whatever posts use
+		t.bySlug[slug] = newTag(config, name, slug)
+	}
+	return t, nil
+}
+
+/*
+ * returns: the Tag a name in front matter files under, err if the name
+ * can't be turned into a safe folder name
+ */
+func (t *tagSet) tag(name string) (Tag, error) {
+	name = strings.TrimSpace(name)
+	slug, err := tagSlug(name)
+	if err != nil {
+		return Tag{}, err
+	}
+	if canonical, ok := t.aliases[slug]; ok {
+		name = canonical
+		slug, _ = tagSlug(canonical)
+	}
+	if tag, ok := t.bySlug[slug]; ok {
+		return tag, nil
+	}
+	// First spelling seen becomes the display name
+	tag := newTag(t.config, name, slug)
+	t.bySlug[slug] = tag
+	return tag, nil
+}
+
+func newTag(config Config, name, slug string) Tag {
+	tag := Tag{TagName: name, URL: buildTagLink(config, slug), Slug: slug}
+	if config.Feeds.PerTag {
+		tag.RSS = tag.URL + "rss.xml"
+		tag.Atom = tag.URL + "atom.xml"
+	}
+	return tag
+}
+
+var tagSpellings = strings.NewReplacer("+", "plus", "#", "sharp")
+
+/*
+ * The link rules of slugify, e.g. "Go Lang" => "go-lang", with + and #
+ * spelled out so C, C++ and C# stay apart
+ */
+func tagSlug(name string) (string, error) {
+	slug := slugify(tagSpellings.Replace(name))
+	if slug == "" {
+		return "", fmt.Errorf("invalid tag %q: must contain a letter or digit", name)
+	}
+	if err := validateLinkName(slug); err != nil {
+		return "", fmt.Errorf("invalid tag %q: %w", name, err)
+	}
+	return slug, nil
+}
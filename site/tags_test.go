@@ -0,0 +1,111 @@
+package site
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTagSlug(t *testing.T) {
+	tests := []struct {
+		name        string
+		expected    string
+		expectError bool
+	}{
+		{"Go", "go", false},
+		{"Go Lang", "go-lang", false},
+		{"  meta  ", "meta", false},
+		{"C/C++", "c-cplusplus", false},
+		{"C#", "csharp", false},
+		{"../etc", "etc", false},
+		{"日本語", "日本語", false},
+		{"Cafe\u0301", "cafe\u0301", false},
+		{"...", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slug, err := tagSlug(tt.name)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("tagSlug(%q) error = %v, expectError %v", tt.name, err, tt.expectError)
+			}
+			if slug != tt.expected {
+				t.Errorf("tagSlug(%q) = %q, expected %q", tt.name, slug, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTagFolding(t *testing.T) {
+	tagged := func(title, link, published string, tags ...string) string {
+		content := post(title, link, published, "public")
+		return strings.Replace(content, "  - meta\n", "  - "+strings.Join(tags, "\n  - ")+"\n", 1)
+	}
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md":  tagged("First", "first", "2025-01-01T10:00:00Z", "Go", "../etc"),
+		"20250102.second.md": tagged("Second", "second", "2025-01-02T10:00:00Z", "go", "Golang", "C/C++"),
+	})
+	s.Config.TagAliases = map[string]string{"golang": "Go"}
+
+	result, err := s.Build(context.Background())
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	second := result.Posts[0]
+	if len(second.Tags) != 2 || second.Tags[0].TagName != "Go" || second.Tags[1].Slug != "c-cplusplus" {
+		t.Errorf("expected go and golang to merge into Go, got %v", second.Tags)
+	}
+	if second.Tags[0].URL != "https://example.com/tags/go/" {
+		t.Errorf("unexpected tag URL %s", second.Tags[0].URL)
+	}
+	for _, name := range []string{"go", "etc", "c-cplusplus"} {
+		if _, err := os.Stat(filepath.Join(s.Config.OutputDir, "tags", name, "index.html")); err != nil {
+			t.Errorf("expected tag page %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"Go", "golang", "C"} {
+		if _, err := os.Stat(filepath.Join(s.Config.OutputDir, "tags", name)); err == nil {
+			t.Errorf("unexpected tag folder %s", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(s.Config.OutputDir, "etc")); err == nil {
+		t.Errorf("tag escaped the tags folder")
+	}
+}
+
+func TestTagNamesFollowOtherPosts(t *testing.T) {
+	tagged := func(title, link, published, tag string) string {
+		return strings.Replace(post(title, link, published, "public"), "  - meta\n", "  - "+tag+"\n", 1)
+	}
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md":  tagged("First", "first", "2025-01-01T10:00:00Z", "Go"),
+		"20250102.second.md": tagged("Second", "second", "2025-01-02T10:00:00Z", "meta"),
+		"20250103.third.md":  tagged("Third", "third", "2025-01-03T10:00:00Z", "go"),
+	})
+	if err := os.WriteFile(filepath.Join(s.Config.TemplatesDir, "default.html"), []byte(`{{ range .Tags }}{{ .TagName }}{{ end }}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	third := filepath.Join(s.Config.OutputDir, "third", "index.html")
+	if page, _ := os.ReadFile(third); string(page) != "Go" {
+		t.Fatalf("expected the first spelling, got %s", page)
+	}
+
+	// third/ isn't a neighbor of first/, but its tag is named by it
+	first := filepath.Join(s.Config.InputDir, "20250101.first.md")
+	if err := os.WriteFile(first, []byte(tagged("First", "first", "2025-01-01T10:00:00Z", "meta")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Build(context.Background()); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if page, _ := os.ReadFile(third); string(page) != "go" {
+		t.Errorf("expected the tag to be renamed after the first post dropped it, got %s", page)
+	}
+}
@@ -0,0 +1,181 @@
+package site
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
+)
+
+/*
+ * Tables of contents, built from a post's headings. `toc: true` in the
+ * front matter fills {{ .TOC }} for the template, and a paragraph holding
+ * nothing but [TOC] puts the table at that spot in the content.
+ */
+
+const (
+	defaultTOCMin = 2
+	defaultTOCMax = 3
+	tocMarker     = "[TOC]"
+)
+
+type TOCEntry struct {
+	Level    int    // 1 for <h1> through 6 for <h6>
+	ID       string // Anchor of the heading
+	Title    string // Heading as plain text
+	Children []TOCEntry
+}
+
+type TOC struct {
+	Entries []TOCEntry    // Top level headings, with deeper ones nested inside
+	HTML    template.HTML // <nav class="toc"> of nested lists, empty without headings
+	Inline  bool          // Already placed in the content by a [TOC] marker
+}
+
+/*
+ * Heading levels to include, from the front matter or the defaults
+ */
+func tocLevels(frontMatter FrontMatter) (int, int) {
+	minLevel, maxLevel := frontMatter.TOCMin, frontMatter.TOCMax
+	if minLevel == 0 {
+		minLevel = defaultTOCMin
+	}
+	if maxLevel == 0 {
+		maxLevel = defaultTOCMax
+	}
+	return minLevel, maxLevel
+}
+
+func validateTOCLevels(frontMatter FrontMatter) []string {
+	var problems []string
+	if level := frontMatter.TOCMin; level < 0 || level > 6 {
+		problems = append(problems, fmt.Sprintf("Invalid value for toc_min: %d", level))
+	}
+	if level := frontMatter.TOCMax; level < 0 || level > 6 {
+		problems = append(problems, fmt.Sprintf("Invalid value for toc_max: %d", level))
+	}
+	if minLevel, maxLevel := tocLevels(frontMatter); len(problems) == 0 && minLevel > maxLevel {
+		problems = append(problems, fmt.Sprintf("Invalid value for toc_max: %d is above toc_min %d", maxLevel, minLevel))
+	}
+	return problems
+}
+
+/*
+ * A paragraph that is nothing but the marker
+ */
+func isTOCMarker(node ast.Node) bool {
+	paragraph, ok := node.(*ast.Paragraph)
+	if !ok || len(paragraph.Children) != 1 {
+		return false
+	}
+	text, ok := paragraph.Children[0].(*ast.Text)
+	return ok && strings.TrimSpace(string(text.Literal)) == tocMarker
+}
+
+/*
+ * Collect the headings of a parsed post
+ *
+ * returns: an empty TOC unless the front matter asks for one or the content
+ * has a marker
+ */
+func buildTOC(doc ast.Node, frontMatter FrontMatter) TOC {
+	minLevel, maxLevel := tocLevels(frontMatter)
+	var flat []TOCEntry
+	inline := false
+
+	/*
+	 * The renderer makes heading IDs unique as it goes, e.g. a second
+	 * {#intro} becomes intro-1. A renderer of our own, fed every heading in
+	 * the same order, arrives at the same anchors.
+	 */
+	ids := mdhtml.NewRenderer(mdhtml.RendererOptions{})
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		if isTOCMarker(node) {
+			inline = true
+			return ast.SkipChildren
+		}
+		heading, ok := node.(*ast.Heading)
+		if !ok || heading.HeadingID == "" {
+			return ast.GoToNext
+		}
+		id := ids.EnsureUniqueHeadingID(heading.HeadingID)
+		if heading.Level < minLevel || heading.Level > maxLevel {
+			return ast.SkipChildren
+		}
+
+		// The title is the heading's children flattened to text
+		var title strings.Builder
+		ast.WalkFunc(heading, func(node ast.Node, entering bool) ast.WalkStatus {
+			if leaf := node.AsLeaf(); leaf != nil && entering {
+				title.Write(leaf.Literal)
+			}
+			return ast.GoToNext
+		})
+		flat = append(flat, TOCEntry{Level: heading.Level, ID: id, Title: strings.TrimSpace(title.String())})
+		return ast.SkipChildren
+	})
+
+	if !frontMatter.TOC && !inline {
+		return TOC{}
+	}
+	toc := TOC{Entries: nestTOC(flat), Inline: inline}
+	if len(toc.Entries) > 0 {
+		toc.HTML = template.HTML(`<nav class="toc">` + tocList(toc.Entries) + `</nav>`)
+	}
+	return toc
+}
+
+/*
+ * Each heading takes the deeper ones that follow it as children, so an
+ * <h4> right after an <h2> is nested directly under it
+ */
+func nestTOC(flat []TOCEntry) []TOCEntry {
+	var entries []TOCEntry
+	for i := 0; i < len(flat); {
+		entry := flat[i]
+		end := i + 1
+		for end < len(flat) && flat[end].Level > entry.Level {
+			end++
+		}
+		entry.Children = nestTOC(flat[i+1 : end])
+		entries = append(entries, entry)
+		i = end
+	}
+	return entries
+}
+
+func tocList(entries []TOCEntry) string {
+	var b strings.Builder
+	b.WriteString("<ul>")
+	for _, entry := range entries {
+		fmt.Fprintf(&b, `<li><a href="#%s">%s</a>`, html.EscapeString(entry.ID), html.EscapeString(entry.Title))
+		if len(entry.Children) > 0 {
+			b.WriteString(tocList(entry.Children))
+		}
+		b.WriteString("</li>")
+	}
+	b.WriteString("</ul>")
+	return b.String()
+}
+
+/*
+ * Render hook for publish: the [TOC] marker is replaced by the table
+ */
+func tocHook(toc TOC) func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	return func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+		if !isTOCMarker(node) {
+			return ast.GoToNext, false
+		}
+		if entering && toc.HTML != "" {
+			io.WriteString(w, string(toc.HTML)+"\n")
+		}
+		return ast.SkipChildren, true
+	}
+}
@@ -0,0 +1,133 @@
+package site
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildTOC(t *testing.T) {
+	md := "# Title\n\n## Install {#setup}\n\n### From *source*\n\n##### Deep\n\n## Usage\n\n## Install\n\n## Setup\n"
+
+	tests := []struct {
+		name        string
+		frontMatter FrontMatter
+		md          string
+		expected    TOC
+	}{
+		{
+			name:        "Disabled",
+			frontMatter: FrontMatter{},
+			md:          md,
+			expected:    TOC{},
+		},
+		{
+			name:        "Defaults",
+			frontMatter: FrontMatter{TOC: true},
+			md:          md,
+			expected: TOC{
+				Entries: []TOCEntry{
+					{Level: 2, ID: "setup", Title: "Install", Children: []TOCEntry{{Level: 3, ID: "from-source", Title: "From source"}}},
+					{Level: 2, ID: "usage", Title: "Usage"},
+					{Level: 2, ID: "install", Title: "Install"},
+					{Level: 2, ID: "setup-1", Title: "Setup"},
+				},
+				HTML: `<nav class="toc"><ul><li><a href="#setup">Install</a><ul><li><a href="#from-source">From source</a></li></ul></li><li><a href="#usage">Usage</a></li><li><a href="#install">Install</a></li><li><a href="#setup-1">Setup</a></li></ul></nav>`,
+			},
+		},
+		{
+			name:        "Levels",
+			frontMatter: FrontMatter{TOC: true, TOCMin: 1, TOCMax: 5},
+			md:          "# Title\n\n##### Deep\n\n### Middle\n",
+			expected: TOC{
+				Entries: []TOCEntry{
+					{Level: 1, ID: "title", Title: "Title", Children: []TOCEntry{
+						{Level: 5, ID: "deep", Title: "Deep"},
+						{Level: 3, ID: "middle", Title: "Middle"},
+					}},
+				},
+				HTML: `<nav class="toc"><ul><li><a href="#title">Title</a><ul><li><a href="#deep">Deep</a></li><li><a href="#middle">Middle</a></li></ul></li></ul></nav>`,
+			},
+		},
+		{
+			name:        "Marker",
+			frontMatter: FrontMatter{},
+			md:          "[TOC]\n\n## Q&A\n",
+			expected: TOC{
+				Entries: []TOCEntry{{Level: 2, ID: "q-a", Title: "Q&A"}},
+				HTML:    `<nav class="toc"><ul><li><a href="#q-a">Q&amp;A</a></li></ul></nav>`,
+				Inline:  true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := buildTOC(parseMarkdown([]byte(tt.md)), tt.frontMatter)
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Errorf("buildTOC() mismatch (-expected +actual):\n%s", diff)
+			}
+
+			// Every entry points at an anchor the renderer actually wrote
+			html := string(publish([]byte(tt.md)))
+			var check func([]TOCEntry)
+			check = func(entries []TOCEntry) {
+				for _, entry := range entries {
+					if !strings.Contains(html, `id="`+entry.ID+`"`) {
+						t.Errorf("no heading with id %s in %s", entry.ID, html)
+					}
+					check(entry.Children)
+				}
+			}
+			check(actual.Entries)
+		})
+	}
+}
+
+func TestTOC(t *testing.T) {
+	withTOC := strings.Replace(post("First", "first", "2025-01-01T10:00:00Z", "public"), "status: public\n", "status: public\ntoc: true\n", 1)
+	withMarker := post("Second", "second", "2025-01-02T10:00:00Z", "public") + "\nIntro\n\n[TOC]\n\n## Details\n"
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md":  withTOC,
+		"20250102.second.md": withMarker,
+	})
+	template := `{{ if not .TOC.Inline }}{{ .TOC.HTML }}{{ end }}|{{ range .TOC.Entries }}{{ .Title }};{{ end }}|{{ .Content }}`
+	if err := os.WriteFile(filepath.Join(s.Config.TemplatesDir, "default.html"), []byte(template), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pass := range []string{"fresh", "cached"} {
+		if _, err := s.Build(context.Background()); err != nil {
+			t.Fatalf("%s: Build() = %v", pass, err)
+		}
+
+		page, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, "first", "index.html"))
+		expected := `<nav class="toc"><ul><li><a href="#hello-world">Hello World</a></li></ul></nav>|Hello World;|`
+		if !strings.HasPrefix(string(page), expected) {
+			t.Errorf("%s: expected %s in %s", pass, expected, page)
+		}
+
+		page, _ = os.ReadFile(filepath.Join(s.Config.OutputDir, "second", "index.html"))
+		expected = "|Hello World;Details;|<h2 id=\"hello-world\">Hello World</h2>\n\n<p>Welcome to my blog.</p>\n\n<p>Intro</p>\n<nav class=\"toc\">"
+		if !strings.HasPrefix(string(page), expected) || strings.Contains(string(page), "[TOC]") {
+			t.Errorf("%s: expected the marker to be replaced in %s", pass, page)
+		}
+	}
+}
+
+func TestCheckTOCLevels(t *testing.T) {
+	content := strings.Replace(post("First", "first", "2025-01-01T10:00:00Z", "public"), "status: public\n", "status: public\ntoc: true\ntoc_max: 9\n", 1)
+	s := newTestSite(t, map[string]string{"20250101.first.md": content})
+
+	report, err := s.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check() = %v", err)
+	}
+	if len(report.Problems) != 1 || report.Problems[0].Message != "Invalid value for toc_max: 9" || report.Problems[0].Line != 12 {
+		t.Errorf("expected a problem with toc_max, got %v", report.Problems)
+	}
+}
@@ -0,0 +1,73 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+ * When a post was last updated: the `updated` header if present, otherwise
+ * what updated_fallback says, otherwise its publish date
+ */
+
+const (
+	UpdatedFallbackGit   = "git"   // Time of the last commit touching the file, or its mtime
+	UpdatedFallbackMtime = "mtime" // The file's modification time
+)
+
+func (s *Site) updatedTime(filePath string, frontMatter FrontMatter, pubTime time.Time) (time.Time, error) {
+	if frontMatter.Updated != "" {
+		updated, err := time.Parse(time.RFC3339, frontMatter.Updated)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("error parsing updated date: %w", err)
+		}
+		return updated, nil
+	}
+
+	var updated time.Time
+	switch s.Config.UpdatedFallback {
+	case UpdatedFallbackGit:
+		updated = gitCommitTime(filePath)
+		if updated.IsZero() {
+			updated = modTime(filePath)
+		}
+	case UpdatedFallbackMtime:
+		updated = modTime(filePath)
+	}
+
+	// A post can't have been updated before it was published
+	if updated.Before(pubTime) {
+		return pubTime, nil
+	}
+	return updated, nil
+}
+
+/*
+ * returns: time of the last commit touching the file, zero if it isn't
+ * tracked or git isn't available
+ */
+func gitCommitTime(filePath string) time.Time {
+	cmd := exec.Command("git", "log", "-1", "--format=%cI", "--", filepath.Base(filePath))
+	cmd.Dir = filepath.Dir(filePath)
+	output, err := cmd.Output()
+	if err != nil {
+		return time.Time{}
+	}
+	commitTime, err := time.Parse(time.RFC3339, strings.TrimSpace(string(output)))
+	if err != nil {
+		return time.Time{}
+	}
+	return commitTime
+}
+
+func modTime(filePath string) time.Time {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,79 @@
+package site
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdatedTime(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "post.md")
+	if err := os.WriteFile(filePath, []byte("---\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(filePath, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	pubTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		updated     string
+		fallback    string
+		pubTime     time.Time
+		expected    time.Time
+		expectError bool
+	}{
+		{name: "No fallback", pubTime: pubTime, expected: pubTime},
+		{name: "Header", updated: "2025-02-01T10:00:00Z", fallback: UpdatedFallbackMtime, pubTime: pubTime, expected: time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{name: "Mtime", fallback: UpdatedFallbackMtime, pubTime: pubTime, expected: mtime},
+		{name: "Git falls back to mtime outside a repository", fallback: UpdatedFallbackGit, pubTime: pubTime, expected: mtime},
+		{name: "Never before published", fallback: UpdatedFallbackMtime, pubTime: mtime.Add(time.Hour), expected: mtime.Add(time.Hour)},
+		{name: "Bad header", updated: "last week", pubTime: pubTime, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Site{Config: Config{UpdatedFallback: tt.fallback}}
+			updated, err := s.updatedTime(filePath, FrontMatter{Updated: tt.updated}, tt.pubTime)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("updatedTime() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !updated.Equal(tt.expected) {
+				t.Errorf("updatedTime() = %v, expected %v", updated, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdatedPropagates(t *testing.T) {
+	updated := strings.Replace(post("First", "first", "2025-01-01T10:00:00Z", "public"), "status: public\n", "status: public\nupdated: 2025-02-01T10:00:00Z\n", 1)
+	s := newTestSite(t, map[string]string{
+		"20250101.first.md": updated,
+	})
+
+	result, err := s.Build(context.Background())
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if result.Posts[0].UpdatedDate != "01-Feb-2025" {
+		t.Errorf("expected UpdatedDate 01-Feb-2025, got %q", result.Posts[0].UpdatedDate)
+	}
+
+	expected := map[string]string{
+		"atom.xml":    "<updated>2025-02-01T10:00:00Z</updated>",
+		"sitemap.xml": "<lastmod>2025-02-01T10:00:00Z</lastmod>",
+		"feed.json":   `"date_modified": "2025-02-01T10:00:00Z"`,
+	}
+	for name, content := range expected {
+		actual, _ := os.ReadFile(filepath.Join(s.Config.OutputDir, name))
+		if !strings.Contains(string(actual), content) {
+			t.Errorf("%s: expected %s", name, content)
+		}
+	}
+}